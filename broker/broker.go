@@ -11,14 +11,22 @@ type Subscription interface {
 	GetId() SubID
 	GetNewHeadsCh() chan event.Block
 	GetLogsCh() chan event.Logs
+	GetPendingTransactionsCh() chan event.Transaction
 	GetLogsSubOpts() request.LogSubscriptionOptions
+	// Err receives an error if the broker closes the subscription, e.g. since its events are not consumed fast enough
+	Err() <-chan error
+	// Dropped returns the number of events which are not delivered to the subscription
+	Dropped() uint64
 }
 
 type Broker interface {
 	SubscribeNewHeads(chan event.Block) Subscription
 	SubscribeLogs(request.LogSubscriptionOptions, chan event.Logs) Subscription
+	SubscribePendingTransactions(chan event.Transaction) Subscription
 	UnsubscribeFromNewHeads(Subscription)
 	UnsubscribeFromLogs(Subscription)
+	UnsubscribeFromPendingTransactions(Subscription)
 	PublishNewHeads(event.Block)
 	PublishLogs(event.Logs)
+	PublishPendingTransaction(event.Transaction)
 }
@@ -1,14 +1,18 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
 
+	dbbadger "github.com/aurora-is-near/relayer2-base/db/badger"
 	"github.com/aurora-is-near/relayer2-base/db/badger/core"
 	"github.com/aurora-is-near/relayer2-base/db/codec"
 	dbt "github.com/aurora-is-near/relayer2-base/types/db"
@@ -18,6 +22,11 @@ import (
 
 var chainId uint64
 var blockType string
+var fromHeight, toHeight uint64
+var targetChainId uint64
+var outputPath string
+var repair bool
+var workers int
 
 func GetLastBlockCmd() *cobra.Command {
 	getLastBlockCmd := &cobra.Command{
@@ -148,6 +157,257 @@ func FlattenDB() *cobra.Command {
 	}
 }
 
+func ExportDB() *cobra.Command {
+	exportDBCmd := &cobra.Command{
+		Use:   "export-db <dbPath>",
+		Short: "Command to export blocks with their transactions and logs as length-prefixed CBOR records",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dbPath := args[0]
+
+			out := cmd.OutOrStdout()
+			if outputPath != "" {
+				f, err := os.Create(outputPath)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+			w := bufio.NewWriter(out)
+			enc := codec.CborEncoder()
+
+			return dbView(dbPath, func(txn *core.ViewTxn) error {
+				from, to := fromHeight, toHeight
+				if from == 0 {
+					key, err := txn.ReadEarliestBlockKey(chainId)
+					if err != nil {
+						return err
+					}
+					if key == nil {
+						return fmt.Errorf("no blocks in db, perhaps you need to provide --chain-id flag?")
+					}
+					from = key.Height
+				}
+				latest, err := txn.ReadLatestBlockKey(chainId)
+				if err != nil {
+					return err
+				}
+				if latest == nil {
+					return fmt.Errorf("no blocks in db, perhaps you need to provide --chain-id flag?")
+				}
+				if to == 0 {
+					to = latest.Height
+				}
+				// only the sequence of the latest indexed block is kept in db, as the indexer state
+				state, err := txn.ReadIndexerState(chainId)
+				if err != nil {
+					return err
+				}
+
+				log.Printf("Exporting blocks from %d to %d...", from, to)
+				count := 0
+				for height := from; height <= to; height++ {
+					block, err := txn.ReadIndexerBlock(chainId, dbt.BlockKey{Height: height})
+					if err != nil {
+						return fmt.Errorf("can't read block %d: %w", height, err)
+					}
+					if block != nil {
+						if height == latest.Height && len(state) == 8 {
+							block.Sequence = binary.BigEndian.Uint64(state)
+						}
+						if err := writeBlockRecord(w, enc, block); err != nil {
+							return err
+						}
+						count++
+					}
+					if height == to {
+						break
+					}
+				}
+				log.Printf("Exported %d blocks", count)
+				return w.Flush()
+			})
+		},
+	}
+	exportDBCmd.PersistentFlags().Uint64VarP(&chainId, "chain-id", "c", 1313161554, "Chain ID")
+	exportDBCmd.PersistentFlags().Uint64Var(&fromHeight, "from", 0, "First block height to export (default earliest)")
+	exportDBCmd.PersistentFlags().Uint64Var(&toHeight, "to", 0, "Last block height to export (default latest)")
+	exportDBCmd.PersistentFlags().StringVarP(&outputPath, "output", "o", "", "Path of the export file (default stdout)")
+	return exportDBCmd
+}
+
+func ImportDB() *cobra.Command {
+	importDBCmd := &cobra.Command{
+		Use:   "import-db <dbPath> [file]",
+		Short: "Command to import blocks exported with export-db, reads from stdin if no file is given",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dbPath := args[0]
+
+			in := cmd.InOrStdin()
+			if len(args) > 1 {
+				f, err := os.Open(args[1])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+			r := bufio.NewReader(in)
+			dec := codec.CborDecoder()
+
+			log.Printf("Opening database at %s...", dbPath)
+			config := &dbbadger.Config{
+				Core: core.Config{
+					RecreateOnCorruption: false,
+					BadgerConfig:         badger.DefaultOptions(dbPath).WithLogger(nil),
+					GcIntervalSeconds:    60 * 60 * 24 * 365, // We don't want GC to be running during the import
+				},
+			}
+			handler, err := dbbadger.NewBlockHandlerWithConfig(config, codec.NewTinypackCodec())
+			if err != nil {
+				return fmt.Errorf("unable to open database: %w", err)
+			}
+			defer func() {
+				log.Printf("Closing database")
+				if err := handler.Close(); err != nil {
+					log.Printf("Error: unable to close database normally: %v", err)
+				}
+			}()
+
+			count := 0
+			for {
+				block, err := readBlockRecord(r, dec)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("can't read block record %d: %w", count, err)
+				}
+				if targetChainId != 0 {
+					block.ChainId = targetChainId
+					for _, tx := range block.Transactions {
+						tx.ChainId = targetChainId
+					}
+				}
+				if err := handler.InsertBlock(block); err != nil {
+					return fmt.Errorf("can't insert block %d: %w", block.Height, err)
+				}
+				if block.Sequence != 0 {
+					state := make([]byte, 8)
+					binary.BigEndian.PutUint64(state, block.Sequence)
+					if err := handler.SetIndexerState(block.ChainId, state); err != nil {
+						return fmt.Errorf("can't restore the indexer state at block %d: %w", block.Height, err)
+					}
+				}
+				count++
+			}
+			log.Printf("Imported %d blocks", count)
+			return nil
+		},
+	}
+	importDBCmd.PersistentFlags().Uint64VarP(&targetChainId, "chain-id", "c", 0, "Chain ID to import the blocks under (default the exported one)")
+	return importDBCmd
+}
+
+func VerifyDB() *cobra.Command {
+	verifyDBCmd := &cobra.Command{
+		Use:   "verify-db <dbPath>",
+		Short: "Command to check that the blocks, transactions and logs in db are consistent with their indexes",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dbPath := args[0]
+
+			log.Printf("Opening database at %s...", dbPath)
+			config := core.Config{
+				RecreateOnCorruption: false,
+				BadgerConfig:         badger.DefaultOptions(dbPath).WithLogger(nil).WithReadOnly(!repair),
+				GcIntervalSeconds:    60 * 60 * 24 * 365, // We don't want GC to be running during the verification
+			}
+			db, err := core.NewDB(config, codec.NewTinypackCodec())
+			if err != nil {
+				return fmt.Errorf("unable to open database: %w", err)
+			}
+			defer func() {
+				if err := db.Close(); err != nil {
+					log.Printf("Error: unable to close database normally: %v", err)
+				}
+			}()
+
+			log.Printf("Verifying database, that might take some time...")
+			report, err := db.Verify(chainId, repair)
+			if err != nil {
+				return err
+			}
+
+			jsonReport, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(jsonReport))
+
+			if !report.Consistent() {
+				return fmt.Errorf("database is inconsistent, see the report above")
+			}
+			return nil
+		},
+	}
+	verifyDBCmd.PersistentFlags().Uint64VarP(&chainId, "chain-id", "c", 1313161554, "Chain ID")
+	verifyDBCmd.PersistentFlags().BoolVar(&repair, "repair", false, "Write back the missing block, transaction and log indexes")
+	return verifyDBCmd
+}
+
+func Reindex() *cobra.Command {
+	reindexCmd := &cobra.Command{
+		Use:   "reindex <dbPath> <family>",
+		Short: "Command to drop a secondary index family and rebuild it from the blocks, transactions and logs in db",
+		Long: fmt.Sprintf("Command to drop a secondary index family (one of %s) and rebuild it from the blocks, "+
+			"transactions and logs in db, an interrupted rebuild is resumed by running the command again",
+			strings.Join(core.IndexFamilyNames(), ", ")),
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dbPath := args[0]
+			family, err := core.ParseIndexFamily(args[1])
+			if err != nil {
+				return err
+			}
+
+			log.Printf("Opening database at %s...", dbPath)
+			config := core.Config{
+				RecreateOnCorruption: false,
+				BadgerConfig:         badger.DefaultOptions(dbPath).WithLogger(nil),
+				GcIntervalSeconds:    60 * 60 * 24 * 365, // We don't want GC to be running during the rebuild
+			}
+			db, err := core.NewDB(config, codec.NewTinypackCodec())
+			if err != nil {
+				return fmt.Errorf("unable to open database: %w", err)
+			}
+			defer func() {
+				if err := db.Close(); err != nil {
+					log.Printf("Error: unable to close database normally: %v", err)
+				}
+			}()
+
+			log.Printf("Rebuilding %s index, that might take some time...", family)
+			err = db.Reindex(chainId, family, core.ReindexOptions{
+				Workers: workers,
+				Progress: func(p core.ReindexProgress) {
+					log.Printf("Rebuilt %s index up to height %d of %d-%d (%d rows)", p.Family, p.Height, p.FromHeight, p.ToHeight, p.Rows)
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("unable to rebuild %s index, run the command again to resume: %w", family, err)
+			}
+			log.Printf("Done")
+			return nil
+		},
+	}
+	reindexCmd.PersistentFlags().Uint64VarP(&chainId, "chain-id", "c", 1313161554, "Chain ID")
+	reindexCmd.PersistentFlags().IntVar(&workers, "workers", runtime.NumCPU(), "Number of write batches built in parallel")
+	return reindexCmd
+}
+
 // dbView opens db in read-only mode and calls fn with ViewTxn
 func dbView(dbPath string, fn func(txn *core.ViewTxn) error) error {
 	config := core.Config{
@@ -159,5 +419,6 @@ func dbView(dbPath string, fn func(txn *core.ViewTxn) error) error {
 	if err != nil {
 		return err
 	}
+	defer db.Close()
 	return db.View(fn)
 }
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aurora-is-near/relayer2-base/types/indexer"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// exportBlock is the export record of an indexer.Block. The fields are encoded the way the blocks of the streams are,
+// so that the records decode into indexer.Block as they are.
+type exportBlock struct {
+	ChainId          uint64               `cbor:"chain_id"`
+	Height           uint64               `cbor:"height"`
+	Sequence         uint64               `cbor:"sequence"`
+	GasLimit         string               `cbor:"gas_limit"`
+	GasUsed          string               `cbor:"gas_used"`
+	Timestamp        uint64               `cbor:"timestamp"`
+	Hash             string               `cbor:"hash"`
+	ParentHash       string               `cbor:"parent_hash"`
+	TransactionsRoot string               `cbor:"transactions_root"`
+	ReceiptsRoot     string               `cbor:"receipts_root"`
+	StateRoot        string               `cbor:"state_root"`
+	Size             string               `cbor:"size"`
+	Miner            string               `cbor:"miner"`
+	LogsBloom        string               `cbor:"logs_bloom"`
+	Transactions     []*exportTransaction `cbor:"transactions"`
+}
+
+type exportTransaction struct {
+	Hash                 string                `cbor:"hash"`
+	BlockHash            string                `cbor:"block_hash"`
+	BlockHeight          uint64                `cbor:"block_height"`
+	ChainId              uint64                `cbor:"chain_id"`
+	TransactionIndex     uint64                `cbor:"transaction_index"`
+	From                 string                `cbor:"from"`
+	To                   *string               `cbor:"to"`
+	Nonce                string                `cbor:"nonce"`
+	GasPrice             string                `cbor:"gas_price"`
+	GasLimit             string                `cbor:"gas_limit"`
+	GasUsed              uint64                `cbor:"gas_used"`
+	MaxPriorityFeePerGas string                `cbor:"max_priority_fee_per_gas"`
+	MaxFeePerGas         string                `cbor:"max_fee_per_gas"`
+	Value                string                `cbor:"value"`
+	Input                []byte                `cbor:"input"`
+	Output               []byte                `cbor:"output"`
+	AccessList           []exportAccessList    `cbor:"access_list"`
+	TxType               uint64                `cbor:"tx_type"`
+	Status               bool                  `cbor:"status"`
+	Logs                 []*exportLog          `cbor:"logs"`
+	LogsBloom            string                `cbor:"logs_bloom"`
+	ContractAddress      *string               `cbor:"contract_address"`
+	V                    uint64                `cbor:"v"`
+	R                    string                `cbor:"r"`
+	S                    string                `cbor:"s"`
+	NearTransaction      exportNearTransaction `cbor:"near_metadata"`
+}
+
+type exportAccessList struct {
+	Address     string   `cbor:"address"`
+	StorageKeys []string `cbor:"storageKeys"`
+}
+
+type exportNearTransaction struct {
+	Hash        *string `cbor:"transaction_hash"`
+	ReceiptHash string  `cbor:"receipt_hash"`
+}
+
+type exportLog struct {
+	Address string   `cbor:"Address"`
+	Topics  [][]byte `cbor:"Topics"`
+	Data    []byte   `cbor:"data"`
+}
+
+func newExportBlock(b *indexer.Block) *exportBlock {
+	eb := exportBlock{
+		ChainId:          b.ChainId,
+		Height:           b.Height,
+		Sequence:         b.Sequence,
+		GasLimit:         b.GasLimit.Hex(),
+		GasUsed:          b.GasUsed.Hex(),
+		Timestamp:        uint64(b.Timestamp),
+		Hash:             b.Hash.Hex(),
+		ParentHash:       b.ParentHash.Hex(),
+		TransactionsRoot: b.TransactionsRoot.Hex(),
+		ReceiptsRoot:     b.ReceiptsRoot.Hex(),
+		StateRoot:        b.StateRoot.Hex(),
+		Size:             fmt.Sprintf("0x%x", uint64(b.Size)),
+		Miner:            b.Miner.Hex(),
+		LogsBloom:        b.LogsBloom.Hex(),
+		Transactions:     make([]*exportTransaction, 0, len(b.Transactions)),
+	}
+	for _, tx := range b.Transactions {
+		eb.Transactions = append(eb.Transactions, newExportTransaction(tx))
+	}
+	return &eb
+}
+
+func newExportTransaction(tx *indexer.Transaction) *exportTransaction {
+	et := exportTransaction{
+		Hash:                 tx.Hash.Hex(),
+		BlockHash:            tx.BlockHash.Hex(),
+		BlockHeight:          tx.BlockHeight,
+		ChainId:              tx.ChainId,
+		TransactionIndex:     tx.TransactionIndex,
+		From:                 tx.From.Hex(),
+		Nonce:                tx.Nonce.Hex(),
+		GasPrice:             tx.GasPrice.Hex(),
+		GasLimit:             tx.GasLimit.Hex(),
+		GasUsed:              tx.GasUsed,
+		MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas.Hex(),
+		MaxFeePerGas:         tx.MaxFeePerGas.Hex(),
+		Value:                tx.Value.Hex(),
+		Input:                tx.Input.Content,
+		Output:               tx.Output.Content,
+		TxType:               tx.TxType,
+		Status:               tx.Status,
+		Logs:                 make([]*exportLog, 0, len(tx.Logs)),
+		LogsBloom:            tx.LogsBloom.Hex(),
+		V:                    tx.V,
+		R:                    tx.R.Hex(),
+		S:                    tx.S.Hex(),
+		NearTransaction: exportNearTransaction{
+			ReceiptHash: base58.Encode(tx.NearTransaction.ReceiptHash.Content),
+		},
+	}
+	if tx.To != nil {
+		to := tx.To.Hex()
+		et.To = &to
+	}
+	if tx.ContractAddress != nil {
+		contract := tx.ContractAddress.Hex()
+		et.ContractAddress = &contract
+	}
+	if tx.NearTransaction.Hash != nil {
+		hash := base58.Encode(tx.NearTransaction.Hash.Content)
+		et.NearTransaction.Hash = &hash
+	}
+	for _, al := range tx.AccessList {
+		eal := exportAccessList{Address: al.Address.Hex()}
+		for _, key := range al.StorageKeys {
+			eal.StorageKeys = append(eal.StorageKeys, key.Hex())
+		}
+		et.AccessList = append(et.AccessList, eal)
+	}
+	for _, l := range tx.Logs {
+		el := exportLog{Address: l.Address.Hex(), Data: l.Data.Content, Topics: make([][]byte, 0, len(l.Topics))}
+		for _, t := range l.Topics {
+			el.Topics = append(el.Topics, t.Content)
+		}
+		et.Logs = append(et.Logs, &el)
+	}
+	return &et
+}
+
+// writeBlockRecord writes the block as CBOR prefixed with its length as a big endian uint32
+func writeBlockRecord(w io.Writer, enc cbor.EncMode, block *indexer.Block) error {
+	data, err := enc.Marshal(newExportBlock(block))
+	if err != nil {
+		return err
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// readBlockRecord reads a block written by writeBlockRecord, returns io.EOF if there are no more records
+func readBlockRecord(r io.Reader, dec cbor.DecMode) (*indexer.Block, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	block := new(indexer.Block)
+	if err := dec.Unmarshal(data, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aurora-is-near/relayer2-base/db/codec"
+	"github.com/aurora-is-near/relayer2-base/types/indexer"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+)
+
+func TestBlockRecordRoundTrip(t *testing.T) {
+	to := primitives.MustData20FromHex("0x2")
+	nearHash := indexer.NearHash(primitives.MustData32FromHex("0x3"))
+	block := indexer.Block{
+		ChainId:          1313161554,
+		Height:           10,
+		Sequence:         12,
+		GasLimit:         primitives.QuantityFromUint64(100),
+		GasUsed:          primitives.QuantityFromUint64(0),
+		Timestamp:        1670000000,
+		Hash:             primitives.MustData32FromHex("0xa"),
+		ParentHash:       primitives.MustData32FromHex("0xb"),
+		TransactionsRoot: primitives.MustData32FromHex("0xc"),
+		ReceiptsRoot:     primitives.MustData32FromHex("0xd"),
+		StateRoot:        primitives.MustData32FromHex("0xe"),
+		Size:             42,
+		Miner:            primitives.MustData20FromHex("0x1"),
+		LogsBloom:        primitives.MustData256FromHex("0xf"),
+		Transactions: []*indexer.Transaction{{
+			Hash:                 primitives.MustData32FromHex("0x10"),
+			BlockHash:            primitives.MustData32FromHex("0xa"),
+			BlockHeight:          10,
+			ChainId:              1313161554,
+			From:                 primitives.MustData20FromHex("0x1"),
+			To:                   &to,
+			Nonce:                primitives.QuantityFromUint64(1),
+			GasPrice:             primitives.QuantityFromUint64(2),
+			GasLimit:             primitives.QuantityFromUint64(3),
+			GasUsed:              21000,
+			MaxPriorityFeePerGas: primitives.QuantityFromUint64(4),
+			MaxFeePerGas:         primitives.QuantityFromUint64(5),
+			Value:                primitives.QuantityFromUint64(6),
+			Input:                indexer.InputOutputData(primitives.VarDataFromBytes([]byte{1, 2, 3})),
+			Output:               indexer.InputOutputData(primitives.VarDataFromBytes([]byte{})),
+			AccessList: []indexer.AccessList{{
+				Address:     primitives.MustData20FromHex("0x4"),
+				StorageKeys: []primitives.Data32{primitives.MustData32FromHex("0x5")},
+			}},
+			TxType: 1,
+			Status: true,
+			Logs: []*indexer.Log{{
+				Address: primitives.MustData20FromHex("0x6"),
+				Topics:  []indexer.Topic{indexer.Topic(primitives.MustData32FromHex("0x7"))},
+				Data:    indexer.InputOutputData(primitives.VarDataFromBytes([]byte{4, 5})),
+			}},
+			LogsBloom:       primitives.MustData256FromHex("0x9"),
+			V:               1,
+			R:               primitives.QuantityFromUint64(7),
+			S:               primitives.QuantityFromUint64(8),
+			NearTransaction: indexer.NearTransaction{Hash: &nearHash, ReceiptHash: indexer.NearHash(primitives.MustData32FromHex("0x8"))},
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeBlockRecord(&buf, codec.CborEncoder(), &block))
+	require.NoError(t, writeBlockRecord(&buf, codec.CborEncoder(), &indexer.Block{Height: 11, Transactions: []*indexer.Transaction{}}))
+	decoded, err := readBlockRecord(&buf, codec.CborDecoder())
+	require.NoError(t, err)
+	require.Equal(t, block, *decoded)
+	decoded, err = readBlockRecord(&buf, codec.CborDecoder())
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), decoded.Height)
+	_, err = readBlockRecord(&buf, codec.CborDecoder())
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/aurora-is-near/relayer2-base/db/badger/core"
 	"github.com/aurora-is-near/relayer2-base/db/badger/core/dbkey"
@@ -29,11 +30,17 @@ func NewBlockHandler() (*BlockHandler, error) {
 }
 
 func NewBlockHandlerWithCodec(codec codec.Codec) (*BlockHandler, error) {
-	config := GetConfig()
+	return NewBlockHandlerWithConfig(GetConfig(), codec)
+}
+
+// NewBlockHandlerWithConfig creates a block handler using the given config instead of the one read from the
+// configuration file, e.g. to open a database given on the command line
+func NewBlockHandlerWithConfig(config *Config, codec codec.Codec) (*BlockHandler, error) {
 	db, err := core.NewDB(config.Core, codec)
 	if err != nil {
 		return nil, err
 	}
+	db.StartPruner()
 	return &BlockHandler{
 		db:     db,
 		Config: config,
@@ -63,42 +70,22 @@ func (h *BlockHandler) BlockNumber(ctx context.Context) (*primitives.HexUint, er
 func (h *BlockHandler) GetBlockByHash(ctx context.Context, hash common.H256, isFull bool) (*response.Block, error) {
 	var resp *response.Block
 	var err error
-	bh := hash.Data32
 	err = h.db.View(func(txn *core.ViewTxn) error {
 		chainId := utils.GetChainId(ctx)
-		key, err := txn.ReadBlockKey(chainId, bh)
+		key, readChainId, err := blockKeyByHash(txn, chainId, hash.Data32)
 		if err != nil {
 			return err
 		}
-		if key == nil {
-			// Provided hash not found in DB, check if this is a prehistory hash
-			prehistoryChainId := utils.GetPrehistoryChainId()
-			if chainId == prehistoryChainId {
-				return &errs.KeyNotFoundError{}
-			} else {
-				blockHeight, err := decodeBlockHeight(bh.Content)
-				if blockHeight == nil || *blockHeight > utils.GetPrehistoryHeight() {
-					return &errs.KeyNotFoundError{}
-				}
-				if err != nil {
-					return &errs.InvalidParamsError{Message: err.Error()}
-				}
-
-				key = &dbt.BlockKey{Height: *blockHeight}
-				resp, err = txn.ReadBlock(prehistoryChainId, *key, isFull)
-				if err != nil {
-					return &errs.KeyNotFoundError{}
-				}
-				if resp != nil {
-					resp, err = postProcessPrehistoryBlock(resp, key.Height, chainId)
-					if err != nil {
-						return err
-					}
-				}
-				return nil
-			}
+		resp, err = txn.ReadBlock(readChainId, *key, isFull)
+		if readChainId == chainId {
+			return err
+		}
+		if err != nil {
+			return &errs.KeyNotFoundError{}
+		}
+		if resp != nil {
+			resp, err = postProcessPrehistoryBlock(resp, key.Height, chainId)
 		}
-		resp, err = txn.ReadBlock(chainId, *key, isFull)
 		return err
 	})
 	return resp, err
@@ -108,63 +95,60 @@ func (h *BlockHandler) GetBlockByNumber(ctx context.Context, number common.BN64,
 	var resp *response.Block
 	var err error
 	err = h.db.View(func(txn *core.ViewTxn) error {
-		var key *dbt.BlockKey
-		var skipPrehistoryChecks bool
-		bn := number.Uint64()
 		chainId := utils.GetChainId(ctx)
-		prehistoryChainId := utils.GetPrehistoryChainId()
-		if chainId == prehistoryChainId {
-			skipPrehistoryChecks = true
+		key, readChainId, err := blockKeyByNumber(txn, chainId, number)
+		if err != nil || key == nil {
+			return err
 		}
-		if bn == nil {
-			key, err = txn.ReadLatestBlockKey(chainId)
-			if err != nil {
-				return err
-			}
-			// Check prehistory blocks if latest block key is nil and prehistory has a different chainId
-			if key == nil && !skipPrehistoryChecks {
-				key, err = txn.ReadLatestBlockKey(prehistoryChainId)
-				if err != nil {
-					return err
-				}
-			}
-		} else if *bn == 0 {
-			key = nil
-			// Check prehistory blocks first if prehistory has a different chainId
-			if !skipPrehistoryChecks {
-				key, err = txn.ReadEarliestBlockKey(prehistoryChainId)
-				if err != nil {
-					return err
-				}
-			}
-			// If prehistory has the same chain with relayer or key not found in prehistory, then check the relayer chain
-			if key == nil {
-				key, err = txn.ReadEarliestBlockKey(chainId)
-				if err != nil {
-					return err
-				}
-			}
-		} else {
-			key = &dbt.BlockKey{Height: *bn}
+		resp, err = txn.ReadBlock(readChainId, *key, isFull)
+		if err != nil || readChainId == chainId {
+			return err
 		}
-		if key != nil {
-			if key.Height >= utils.GetPrehistoryHeight() || skipPrehistoryChecks {
-				resp, err = txn.ReadBlock(chainId, *key, isFull)
-			} else {
-				resp, err = txn.ReadBlock(prehistoryChainId, *key, isFull)
-				if err != nil {
-					return err
-				}
-				if resp != nil {
-					resp, err = postProcessPrehistoryBlock(resp, key.Height, chainId)
-				}
-			}
+		if resp != nil {
+			resp, err = postProcessPrehistoryBlock(resp, key.Height, chainId)
 		}
 		return err
 	})
 	return resp, err
 }
 
+// GetBlockReceipts returns the receipts of all the transactions of the block with the given number or hash, an empty
+// list for the prehistory blocks since they have no transactions
+func (h *BlockHandler) GetBlockReceipts(ctx context.Context, blockNumberOrHash common.BlockNumberOrHash) ([]*response.TransactionReceipt, error) {
+	var resp []*response.TransactionReceipt
+	var err error
+	err = h.db.View(func(txn *core.ViewTxn) error {
+		var key *dbt.BlockKey
+		var readChainId uint64
+		chainId := utils.GetChainId(ctx)
+		if hash, ok := blockNumberOrHash.Hash(); ok {
+			key, readChainId, err = blockKeyByHash(txn, chainId, hash.Data32)
+		} else if number, ok := blockNumberOrHash.Number(); ok {
+			key, readChainId, err = blockKeyByNumber(txn, chainId, number)
+		} else {
+			return &errs.InvalidParamsError{Message: "block number or hash is required"}
+		}
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return &errs.KeyNotFoundError{}
+		}
+		resp, err = txn.ReadBlockReceipts(readChainId, *key)
+		if err != nil {
+			return err
+		}
+		if resp == nil {
+			return &errs.KeyNotFoundError{}
+		}
+		if readChainId != chainId {
+			resp = []*response.TransactionReceipt{}
+		}
+		return nil
+	})
+	return resp, err
+}
+
 func (h *BlockHandler) GetBlockTransactionCountByHash(ctx context.Context, hash common.H256) (*primitives.HexUint, error) {
 	var resp primitives.HexUint
 	var err error
@@ -180,6 +164,9 @@ func (h *BlockHandler) GetBlockTransactionCountByHash(ctx context.Context, hash
 			resp = 0
 			return nil
 		}
+		if err := checkPruned(txn, chainId, key.Height); err != nil {
+			return err
+		}
 		resp, err = txn.ReadBlockTxCount(chainId, *key)
 		return err
 	})
@@ -199,6 +186,9 @@ func (h *BlockHandler) GetBlockTransactionCountByNumber(ctx context.Context, num
 				return err
 			}
 		} else {
+			if err := checkPruned(txn, chainId, *bn); err != nil {
+				return err
+			}
 			key = &dbt.BlockKey{Height: *bn}
 		}
 		resp, err = txn.ReadBlockTxCount(chainId, *key)
@@ -221,6 +211,9 @@ func (h *BlockHandler) GetTransactionByHash(ctx context.Context, hash common.H25
 		if key == nil {
 			return &errs.KeyNotFoundError{}
 		}
+		if err := checkPruned(txn, chainId, key.BlockHeight); err != nil {
+			return err
+		}
 		resp, err = txn.ReadTx(chainId, *key)
 		return err
 	})
@@ -240,6 +233,9 @@ func (h *BlockHandler) GetTransactionByBlockHashAndIndex(ctx context.Context, ha
 		if key == nil {
 			return &errs.KeyNotFoundError{}
 		}
+		if err := checkPruned(txn, chainId, key.Height); err != nil {
+			return err
+		}
 		resp, err = txn.ReadTx(chainId, dbt.TransactionKey{
 			BlockHeight:      key.Height,
 			TransactionIndex: index.Uint64(),
@@ -262,6 +258,8 @@ func (h *BlockHandler) GetTransactionByBlockNumberAndIndex(ctx context.Context,
 				return err
 			}
 			bn = &key.Height
+		} else if err := checkPruned(txn, chainId, *bn); err != nil {
+			return err
 		}
 		resp, err = txn.ReadTx(chainId, dbt.TransactionKey{
 			BlockHeight:      *bn,
@@ -286,6 +284,9 @@ func (h *BlockHandler) GetTransactionReceipt(ctx context.Context, hash common.H2
 		if key == nil {
 			return &errs.KeyNotFoundError{}
 		}
+		if err := checkPruned(txn, chainId, key.BlockHeight); err != nil {
+			return err
+		}
 		resp, err = txn.ReadTxReceipt(chainId, *key)
 		return err
 	})
@@ -302,6 +303,24 @@ func (h *BlockHandler) GetLogs(ctx context.Context, filter *dbt.LogFilter) ([]*r
 	return resp, err
 }
 
+// GetLogsPage works like GetLogs but, instead of failing when the response limits are exceeded, returns the logs read
+// so far along with the key of the next log to read. The returned key is nil once the whole filter range is read.
+func (h *BlockHandler) GetLogsPage(ctx context.Context, filter *dbt.LogFilter) ([]*response.Log, *dbt.LogKey, error) {
+	var resp []*response.Log
+	var next *dbt.LogKey
+	err := h.db.View(func(txn *core.ViewTxn) error {
+		var lastKey *dbt.LogKey
+		var err error
+		resp, lastKey, err = h.getLogs(ctx, txn, filter, true)
+		if _, ok := err.(*errs.LogResponseRangeLimitError); ok && lastKey != nil {
+			next = lastKey.Next()
+			return nil
+		}
+		return err
+	})
+	return resp, next, err
+}
+
 func (h *BlockHandler) GetFilterLogs(ctx context.Context, filter *dbt.LogFilter) ([]*response.Log, error) {
 	return h.GetLogs(ctx, filter)
 }
@@ -309,7 +328,9 @@ func (h *BlockHandler) GetFilterLogs(ctx context.Context, filter *dbt.LogFilter)
 func (h *BlockHandler) GetFilterChanges(ctx context.Context, filter any) (*[]interface{}, error) {
 	var err error
 	filterChanges := make([]interface{}, 0)
+	polledAt := uint64(time.Now().UnixNano())
 	if bf, ok := filter.(*dbt.BlockFilter); ok {
+		bf.LastPolledAt = polledAt
 		var blockHashes []primitives.Data32
 		var lastKey *dbt.BlockKey
 		err = h.db.View(func(txn *core.ViewTxn) error {
@@ -323,6 +344,7 @@ func (h *BlockHandler) GetFilterChanges(ctx context.Context, filter any) (*[]int
 			filterChanges = append(filterChanges, log)
 		}
 	} else if tf, ok := filter.(*dbt.TransactionFilter); ok {
+		tf.LastPolledAt = polledAt
 		var txnHashes []any
 		var lastKey *dbt.TransactionKey
 		err = h.db.View(func(txn *core.ViewTxn) error {
@@ -336,6 +358,7 @@ func (h *BlockHandler) GetFilterChanges(ctx context.Context, filter any) (*[]int
 			return err
 		})
 	} else if lf, ok := filter.(*dbt.LogFilter); ok {
+		lf.LastPolledAt = polledAt
 		var logs []*response.Log
 		var lastKey *dbt.LogKey
 		err = h.db.View(func(txn *core.ViewTxn) error {
@@ -379,8 +402,11 @@ func (h *BlockHandler) BlockHashToNumber(ctx context.Context, hash common.H256)
 		if key == nil {
 			return &errs.KeyNotFoundError{}
 		}
+		if err := checkPruned(txn, chainId, key.Height); err != nil {
+			return err
+		}
 		resp = key.Height
-		return err
+		return nil
 	})
 	return &resp, err
 }
@@ -391,6 +417,9 @@ func (h *BlockHandler) BlockNumberToHash(ctx context.Context, number common.BN64
 	err = h.db.View(func(txn *core.ViewTxn) error {
 		var b *response.Block
 		chainId := utils.GetChainId(ctx)
+		if err := checkPruned(txn, chainId, *number.Uint64()); err != nil {
+			return err
+		}
 		b, err = txn.ReadBlock(chainId, dbt.BlockKey{
 			Height: *number.Uint64(),
 		}, false)
@@ -444,6 +473,10 @@ func (h *BlockHandler) InsertBlock(block *indexer.Block) error {
 	return nil
 }
 
+func (h *BlockHandler) RevertToHeight(ctx context.Context, height uint64) ([]*response.Log, error) {
+	return h.db.RevertToHeight(utils.GetChainId(ctx), height)
+}
+
 func (h *BlockHandler) SetIndexerState(chainId uint64, data []byte) error {
 	return h.db.InsertIndexerState(chainId, data)
 }
@@ -473,6 +506,15 @@ func (h *BlockHandler) getLogs(ctx context.Context, txn *core.ViewTxn, filter *d
 		// for GetFilterChanges (i.e.: ignoreNext = false) and non-zero 'next' case use next as 'from'
 		from = &filter.Next
 	}
+	// ranges reaching below the pruned height, as well as the filters created before pruning, are served from the
+	// pruned height on
+	pruned, err := txn.ReadPrunedHeight(chainId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pruned != nil && from.BlockHeight < *pruned {
+		from = &dbt.LogKey{BlockHeight: *pruned}
+	}
 
 	if filter.To.BlockHeight == 0 && filter.To.TransactionIndex == dbkey.MaxTxIndex && filter.To.LogIndex == dbkey.MaxLogIndex {
 		// use the latest block key if initial 'to' is all set to defaults
@@ -593,6 +635,102 @@ func (h *BlockHandler) getTransactionHashes(ctx context.Context, txn *core.ViewT
 	return resp, lastKey, err
 }
 
+// blockKeyByHash returns the key of the block with the given hash and the chain id to read the block from, which is
+// the prehistory chain id if the hash is a prehistory block hash. Returns errs.KeyNotFoundError if the hash is unknown
+// and errs.PrunedError if the block is below the pruned height. Since pruning deletes the hash indexes, the hashes of
+// the pruned blocks are unknown once the pruning is done.
+func blockKeyByHash(txn *core.ViewTxn, chainId uint64, hash primitives.Data32) (*dbt.BlockKey, uint64, error) {
+	key, err := txn.ReadBlockKey(chainId, hash)
+	if err != nil {
+		return nil, 0, err
+	}
+	if key != nil {
+		if err := checkPruned(txn, chainId, key.Height); err != nil {
+			return nil, 0, err
+		}
+		return key, chainId, nil
+	}
+	// Provided hash not found in DB, check if this is a prehistory hash
+	prehistoryChainId := utils.GetPrehistoryChainId()
+	if chainId == prehistoryChainId {
+		return nil, 0, &errs.KeyNotFoundError{}
+	}
+	blockHeight, err := decodeBlockHeight(hash.Content)
+	if blockHeight == nil || *blockHeight > utils.GetPrehistoryHeight() {
+		return nil, 0, &errs.KeyNotFoundError{}
+	}
+	if err != nil {
+		return nil, 0, &errs.InvalidParamsError{Message: err.Error()}
+	}
+	return &dbt.BlockKey{Height: *blockHeight}, prehistoryChainId, nil
+}
+
+// blockKeyByNumber resolves the block number tags and returns the key of the block with the chain id to read the block
+// from, which is the prehistory chain id for the blocks below the prehistory height. Returns a nil key if there is
+// no block for the tag.
+func blockKeyByNumber(txn *core.ViewTxn, chainId uint64, number common.BN64) (*dbt.BlockKey, uint64, error) {
+	var key *dbt.BlockKey
+	var err error
+	var skipPrehistoryChecks bool
+	bn := number.Uint64()
+	prehistoryChainId := utils.GetPrehistoryChainId()
+	if chainId == prehistoryChainId {
+		skipPrehistoryChecks = true
+	}
+	if bn == nil {
+		key, err = txn.ReadLatestBlockKey(chainId)
+		if err != nil {
+			return nil, 0, err
+		}
+		// Check prehistory blocks if latest block key is nil and prehistory has a different chainId
+		if key == nil && !skipPrehistoryChecks {
+			key, err = txn.ReadLatestBlockKey(prehistoryChainId)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+	} else if *bn == 0 {
+		// Check prehistory blocks first if prehistory has a different chainId
+		if !skipPrehistoryChecks {
+			key, err = txn.ReadEarliestBlockKey(prehistoryChainId)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		// If prehistory has the same chain with relayer or key not found in prehistory, then check the relayer chain
+		if key == nil {
+			key, err = txn.ReadEarliestBlockKey(chainId)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+	} else {
+		key = &dbt.BlockKey{Height: *bn}
+	}
+	readChainId := prehistoryChainId
+	if key == nil || key.Height >= utils.GetPrehistoryHeight() || skipPrehistoryChecks {
+		readChainId = chainId
+	}
+	if key != nil && bn != nil && *bn != 0 {
+		if err := checkPruned(txn, readChainId, key.Height); err != nil {
+			return nil, 0, err
+		}
+	}
+	return key, readChainId, nil
+}
+
+// checkPruned returns errs.PrunedError if the blocks at the given height have been pruned by the retention policy
+func checkPruned(txn *core.ViewTxn, chainId, height uint64) error {
+	pruned, err := txn.ReadPrunedHeight(chainId)
+	if err != nil {
+		return err
+	}
+	if pruned != nil && height < *pruned {
+		return &errs.PrunedError{EarliestHeight: *pruned}
+	}
+	return nil
+}
+
 // postProcessPrehistoryBlock updates the block hash and parent hash fields of the prehistory block according to the prehistory chainId
 func postProcessPrehistoryBlock(preBlock *response.Block, blockHeight, chainId uint64) (*response.Block, error) {
 	var err error
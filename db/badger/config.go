@@ -12,6 +12,8 @@ import (
 const (
 	defaultGcIntervalSeconds     = 10
 	defaultLogFilterTtlMinutes   = 15
+	defaultFilterGcIntervalSecs  = 60
+	defaultPruneIntervalSeconds  = 60
 	defaultLogScanRangeThreshold = 3000
 	defaultLogMaxScanIterators   = 10000
 	defaultDataPath              = "/tmp/badger/data"
@@ -28,12 +30,14 @@ func defaultConfig() *Config {
 	badgerOptions.Logger = NewBadgerLogger(log.Log())
 	return &Config{
 		Core: core.Config{
-			MaxScanIterators:     defaultLogMaxScanIterators,
-			ScanRangeThreshold:   defaultLogScanRangeThreshold,
-			FilterTtlMinutes:     defaultLogFilterTtlMinutes,
-			GcIntervalSeconds:    defaultGcIntervalSeconds,
-			RecreateOnCorruption: false,
-			BadgerConfig:         badgerOptions,
+			MaxScanIterators:        defaultLogMaxScanIterators,
+			ScanRangeThreshold:      defaultLogScanRangeThreshold,
+			FilterTtlMinutes:        defaultLogFilterTtlMinutes,
+			FilterGcIntervalSeconds: defaultFilterGcIntervalSecs,
+			GcIntervalSeconds:       defaultGcIntervalSeconds,
+			PruneIntervalSeconds:    defaultPruneIntervalSeconds,
+			RecreateOnCorruption:    false,
+			BadgerConfig:            badgerOptions,
 		},
 	}
 }
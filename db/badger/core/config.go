@@ -3,10 +3,14 @@ package core
 import "github.com/dgraph-io/badger/v3"
 
 type Config struct {
-	MaxScanIterators     uint           `mapstructure:"maxScanIterators"`
-	ScanRangeThreshold   uint           `mapstructure:"scanRangeThreshold"`
-	FilterTtlMinutes     int            `mapstructure:"filterTtlMinutes"`
-	GcIntervalSeconds    int            `mapstructure:"gcIntervalSeconds"`
-	RecreateOnCorruption bool           `mapstructure:"recreateOnCorruption"`
-	BadgerConfig         badger.Options `mapstructure:"options"`
+	MaxScanIterators        uint           `mapstructure:"maxScanIterators"`
+	ScanRangeThreshold      uint           `mapstructure:"scanRangeThreshold"`
+	FilterTtlMinutes        int            `mapstructure:"filterTtlMinutes"`
+	FilterGcIntervalSeconds int            `mapstructure:"filterGcIntervalSeconds"`
+	GcIntervalSeconds       int            `mapstructure:"gcIntervalSeconds"`
+	RetainBlocks            uint64         `mapstructure:"retainBlocks"`
+	RetainMaxAgeMinutes     int            `mapstructure:"retainMaxAgeMinutes"`
+	PruneIntervalSeconds    int            `mapstructure:"pruneIntervalSeconds"`
+	RecreateOnCorruption    bool           `mapstructure:"recreateOnCorruption"`
+	BadgerConfig            badger.Options `mapstructure:"options"`
 }
@@ -4,43 +4,90 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aurora-is-near/relayer2-base/log"
-	"github.com/aurora-is-near/relayer2-base/syncutils"
 
 	"github.com/dgraph-io/badger/v3"
 )
 
-var bdbPtr syncutils.LockablePtr[badger.DB]
-var gcStop chan bool
+// Handle is a badger instance together with its value log GC goroutine. The instances opened on a directory are shared
+// by all the DBs of the process using that directory, since badger locks it, and closed once all of them are closed.
+// The in-memory instances are never shared.
+type Handle struct {
+	bdb    *badger.DB
+	dir    string
+	refs   int
+	gcStop chan bool
+}
 
-func Open(options badger.Options, gcIntervalSeconds int, recreateOnCorruption bool) (*badger.DB, error) {
-	var err error
-	bdb, unlock := bdbPtr.LockIfNil()
-	if unlock != nil {
-		bdb, err = tryOpen(options, gcIntervalSeconds, recreateOnCorruption)
-		unlock(bdb)
+var (
+	handlesMtx sync.Mutex
+	handles    = map[string]*Handle{}
+)
+
+func Open(options badger.Options, gcIntervalSeconds int, recreateOnCorruption bool) (*Handle, error) {
+	if options.InMemory {
+		return openHandle("", options, gcIntervalSeconds, recreateOnCorruption)
 	}
-	return bdb, err
+
+	dir, err := filepath.Abs(options.Dir)
+	if err != nil {
+		return nil, err
+	}
+	handlesMtx.Lock()
+	defer handlesMtx.Unlock()
+	if h, ok := handles[dir]; ok {
+		h.refs++
+		return h, nil
+	}
+	h, err := openHandle(dir, options, gcIntervalSeconds, recreateOnCorruption)
+	if err != nil {
+		return nil, err
+	}
+	handles[dir] = h
+	return h, nil
 }
 
-func Close() error {
-	bdb, unlock := bdbPtr.LockIfNotNil()
-	if unlock != nil {
-		gcStop <- true
-		log.Log().Info().Msg("closing database")
-		if err := bdb.Close(); err != nil {
-			unlock(bdb)
-			return err
-		}
-		unlock(nil)
+func openHandle(dir string, options badger.Options, gcIntervalSeconds int, recreateOnCorruption bool) (*Handle, error) {
+	bdb, err := tryOpen(options, recreateOnCorruption)
+	if err != nil {
+		return nil, err
+	}
+	h := &Handle{
+		bdb:    bdb,
+		dir:    dir,
+		refs:   1,
+		gcStop: make(chan bool),
+	}
+	go runGC(bdb, gcIntervalSeconds, h.gcStop)
+	return h, nil
+}
+
+// Close releases the handle, the badger instance is closed when the last of its users releases it
+func (h *Handle) Close() error {
+	handlesMtx.Lock()
+	defer handlesMtx.Unlock()
+	if h.refs--; h.refs > 0 {
+		return nil
+	}
+	if h.dir != "" {
+		delete(handles, h.dir)
 	}
-	return nil
+	close(h.gcStop)
+	log.Log().Info().Msg("closing database")
+	return h.bdb.Close()
 }
 
-func tryOpen(options badger.Options, gcIntervalSeconds int, recreateOnCorruption bool) (*badger.DB, error) {
+// DB returns the underlying badger instance
+func (h *Handle) DB() *badger.DB {
+	return h.bdb
+}
+
+func tryOpen(options badger.Options, recreateOnCorruption bool) (*badger.DB, error) {
 	var err error
 	logger := log.Log()
 
@@ -75,25 +122,20 @@ func tryOpen(options badger.Options, gcIntervalSeconds int, recreateOnCorruption
 		logger.Info().Err(err).Msg("creating new database")
 		bdb, err = badger.Open(options)
 	}
-	if bdb != nil {
-		gcStop = make(chan bool)
-		go runGC(bdb, gcIntervalSeconds)
-	}
-
 	return bdb, err
 }
 
-func runGC(bdb *badger.DB, gcIntervalSeconds int) {
+func runGC(bdb *badger.DB, gcIntervalSeconds int, stop chan bool) {
 	ticker := time.NewTicker(time.Duration(gcIntervalSeconds) * time.Second)
 	defer ticker.Stop()
 	for {
 		select {
-		case <-gcStop:
+		case <-stop:
 			return
 		case <-ticker.C:
 			for {
 				select {
-				case <-gcStop:
+				case <-stop:
 					return
 				default:
 				}
@@ -104,3 +146,39 @@ func runGC(bdb *badger.DB, gcIntervalSeconds int) {
 		}
 	}
 }
+
+func runFilterGC(db *DB, intervalSeconds int, stop chan bool) {
+	ticker := time.NewTicker(time.Duration(intervalSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			n, err := db.DeleteExpiredFilters(time.Now())
+			if err != nil {
+				log.Log().Error().Err(err).Msg("failed to delete expired filters")
+			} else if n > 0 {
+				log.Log().Debug().Msgf("deleted %d expired filters", n)
+			}
+		}
+	}
+}
+
+func runPruner(db *DB, intervalSeconds int, stop chan bool) {
+	ticker := time.NewTicker(time.Duration(intervalSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			n, err := db.Prune(time.Now())
+			if err != nil {
+				log.Log().Error().Err(err).Msg("failed to prune blocks")
+			} else if n > 0 {
+				log.Log().Info().Msgf("pruned %d blocks", n)
+			}
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package core
 
 import (
+	"sync"
+	"time"
+
 	"github.com/aurora-is-near/relayer2-base/db/codec"
 
 	"github.com/dgraph-io/badger/v3"
@@ -12,12 +15,20 @@ type DB struct {
 	maxLogScanIterators   uint // Should be somewhere between 1k and 100k
 	logScanRangeThreshold uint // Minimum block range size for using index instead of simple iteration
 	filterTtlMinutes      int
+	filterGcInterval      int
+	filterGcStop          chan bool
+	retainBlocks          uint64
+	retainMaxAgeMinutes   int
+	pruneInterval         int
+	pruneStop             chan bool
+	background            sync.WaitGroup
 	logger                badger.Logger
+	handle                *Handle
 	core                  *badger.DB
 }
 
 func NewDB(config Config, codec codec.Codec) (*DB, error) {
-	core, err := Open(config.BadgerConfig, config.GcIntervalSeconds, config.RecreateOnCorruption)
+	handle, err := Open(config.BadgerConfig, config.GcIntervalSeconds, config.RecreateOnCorruption)
 	if err != nil {
 		return nil, err
 	}
@@ -26,8 +37,13 @@ func NewDB(config Config, codec codec.Codec) (*DB, error) {
 		maxLogScanIterators:   config.MaxScanIterators,
 		logScanRangeThreshold: config.ScanRangeThreshold,
 		filterTtlMinutes:      config.FilterTtlMinutes,
+		filterGcInterval:      config.FilterGcIntervalSeconds,
+		retainBlocks:          config.RetainBlocks,
+		retainMaxAgeMinutes:   config.RetainMaxAgeMinutes,
+		pruneInterval:         config.PruneIntervalSeconds,
 		logger:                config.BadgerConfig.Logger,
-		core:                  core,
+		handle:                handle,
+		core:                  handle.DB(),
 	}
 	return db, nil
 }
@@ -59,8 +75,53 @@ func (db *DB) Update(fn func(txn *ViewTxn) error) error {
 	})
 }
 
+// StartFilterGC starts the background sweeper deleting the expired filters, it is stopped on Close
+func (db *DB) StartFilterGC() {
+	if db.filterGcStop != nil || db.filterTtlMinutes <= 0 || db.filterGcInterval <= 0 {
+		return
+	}
+	db.filterGcStop = make(chan bool)
+	db.background.Add(1)
+	go func(stop chan bool) {
+		defer db.background.Done()
+		runFilterGC(db, db.filterGcInterval, stop)
+	}(db.filterGcStop)
+}
+
+func (db *DB) filterTtl() time.Duration {
+	return time.Duration(db.filterTtlMinutes) * time.Minute
+}
+
+// StartPruner starts the background pruner deleting the blocks out of the retention window, it is stopped on Close
+func (db *DB) StartPruner() {
+	if db.pruneStop != nil || (db.retainBlocks == 0 && db.retainMaxAgeMinutes <= 0) || db.pruneInterval <= 0 {
+		return
+	}
+	db.pruneStop = make(chan bool)
+	db.background.Add(1)
+	go func(stop chan bool) {
+		defer db.background.Done()
+		runPruner(db, db.pruneInterval, stop)
+	}(db.pruneStop)
+}
+
 func (db *DB) Close() error {
-	return Close()
+	if db.filterGcStop != nil {
+		close(db.filterGcStop)
+		db.filterGcStop = nil
+	}
+	if db.pruneStop != nil {
+		close(db.pruneStop)
+		db.pruneStop = nil
+	}
+	// a sweep or a pruning in flight is let to finish before the handle is released
+	db.background.Wait()
+	if db.handle == nil {
+		return nil
+	}
+	err := db.handle.Close()
+	db.handle = nil
+	return err
 }
 
 // For debugging/testing/etc purposes
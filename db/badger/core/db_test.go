@@ -1,6 +1,7 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"log"
@@ -8,13 +9,16 @@ import (
 	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/aurora-is-near/relayer2-base/db/badger/core/dbkey"
 	"github.com/aurora-is-near/relayer2-base/db/badger/core/logscan"
 	"github.com/aurora-is-near/relayer2-base/db/codec"
 	"github.com/aurora-is-near/relayer2-base/tinypack"
 	dbt "github.com/aurora-is-near/relayer2-base/types/db"
 	"github.com/aurora-is-near/relayer2-base/types/primitives"
 	"github.com/aurora-is-near/relayer2-base/types/response"
+	"github.com/aurora-is-near/relayer2-base/utils"
 	"golang.org/x/crypto/sha3"
 
 	"github.com/dgraph-io/badger/v3"
@@ -379,7 +383,7 @@ func initTestDb(t *testing.T) (*DB, *testLogger) {
 
 	opts := badger.DefaultOptions("")
 	opts.InMemory = true
-	core, err := Open(opts, 10, false)
+	handle, err := Open(opts, 10, false)
 	require.NoError(t, err, "DB must tryOpen")
 
 	logger := testLogger{}
@@ -389,7 +393,8 @@ func initTestDb(t *testing.T) (*DB, *testLogger) {
 		logScanRangeThreshold: 1000,
 		filterTtlMinutes:      15,
 		logger:                logger,
-		core:                  core,
+		handle:                handle,
+		core:                  handle.DB(),
 	}
 
 	// testDb := &DB{
@@ -752,6 +757,80 @@ func getLogSearchExpectedResult(
 	return result, to, nil
 }
 
+func TestReadBlockReceipts(t *testing.T) {
+	testView(t, true, true, func(txn *ViewTxn) error {
+		for _, blockSeed := range blockSeeds {
+			expected := []*response.TransactionReceipt{}
+			for _, txSeed := range txSeeds {
+				if txSeed.height != blockSeed.height {
+					continue
+				}
+				logs := []logSeed{}
+				for _, logSeed := range logSeeds {
+					if logSeed.height == txSeed.height && logSeed.txIndex == txSeed.index {
+						logs = append(logs, logSeed)
+					}
+				}
+				expected = append(expected, txSeed.getTxReceiptResponse(logs))
+			}
+
+			receipts, err := txn.ReadBlockReceipts(testChainId, *blockSeed.getBlockKey())
+			require.NoError(t, err, "ReadBlockReceipts must work")
+			require.Equal(t, expected, receipts, "ReadBlockReceipts must return right value")
+		}
+
+		receipts, err := txn.ReadBlockReceipts(testChainId, dbt.BlockKey{Height: 102})
+		require.NoError(t, err, "ReadBlockReceipts must work for missing block")
+		require.Nil(t, receipts, "ReadBlockReceipts must return nil for missing block")
+
+		return nil
+	})
+}
+
+func TestReadIndexerBlock(t *testing.T) {
+	testView(t, true, true, func(txn *ViewTxn) error {
+		for _, blockSeed := range blockSeeds {
+			block, err := txn.ReadIndexerBlock(testChainId, *blockSeed.getBlockKey())
+			require.NoError(t, err, "ReadIndexerBlock must work")
+			require.NotNil(t, block, "ReadIndexerBlock must find the block")
+			require.Equal(t, blockSeed.getBlockHash(), block.Hash, "ReadIndexerBlock must return right hash")
+			require.Equal(t, blockSeed.getBlockData(), utils.IndexerBlockToDbBlock(block), "ReadIndexerBlock must return right value")
+
+			txs := []txSeed{}
+			for _, txSeed := range txSeeds {
+				if txSeed.height == blockSeed.height {
+					txs = append(txs, txSeed)
+				}
+			}
+			require.Len(t, block.Transactions, len(txs), "ReadIndexerBlock must return all transactions")
+			for i, txSeed := range txs {
+				tx := block.Transactions[i]
+				require.Equal(t, txSeed.index, tx.TransactionIndex, "ReadIndexerBlock must return transactions in order")
+				require.Equal(t, txSeed.getTxHash(), tx.Hash, "ReadIndexerBlock must return right tx hash")
+				require.Equal(t, txSeed.getTxData().From, tx.From, "ReadIndexerBlock must return right tx data")
+				require.Equal(t, txSeed.getTxData().Input, primitives.VarData(tx.Input), "ReadIndexerBlock must return right tx data")
+
+				logs := []logSeed{}
+				for _, logSeed := range logSeeds {
+					if logSeed.height == txSeed.height && logSeed.txIndex == txSeed.index {
+						logs = append(logs, logSeed)
+					}
+				}
+				require.Len(t, tx.Logs, len(logs), "ReadIndexerBlock must return all logs")
+				for j, logSeed := range logs {
+					require.Equal(t, logSeed.getLogData(), utils.IndexerLogToDbLog(tx.Logs[j]), "ReadIndexerBlock must return right log")
+				}
+			}
+		}
+
+		block, err := txn.ReadIndexerBlock(testChainId, dbt.BlockKey{Height: 102})
+		require.NoError(t, err, "ReadIndexerBlock must work for missing block")
+		require.Nil(t, block, "ReadIndexerBlock must return nil for missing block")
+
+		return nil
+	})
+}
+
 func TestReadLog(t *testing.T) {
 	testView(t, true, true, func(txn *ViewTxn) error {
 		earliestLogKey, err := txn.ReadEarliestLogKey(testChainId)
@@ -870,3 +949,432 @@ func TestReadLog(t *testing.T) {
 // 		return nil
 // 	})
 // }
+
+func TestRevertToHeight(t *testing.T) {
+	testDb, logger := initTestDb(t)
+	defer testDb.Close()
+
+	const revertHeight = 9_000_003
+	removed, err := testDb.RevertToHeight(testChainId, revertHeight)
+	require.NoError(t, err, "RevertToHeight must work")
+
+	expectedRemoved := []*response.Log{}
+	for _, logSeed := range logSeeds {
+		if logSeed.height > revertHeight {
+			l := logSeed.getLogResponse()
+			l.Removed = true
+			expectedRemoved = append(expectedRemoved, l)
+		}
+	}
+	require.Equal(t, expectedRemoved, removed, "RevertToHeight must return the removed logs")
+
+	require.NoError(t, testDb.View(func(txn *ViewTxn) error {
+		latestBlockKey, err := txn.ReadLatestBlockKey(testChainId)
+		require.NoError(t, err, "ReadLatestBlockKey must work")
+		require.Equal(t, &dbt.BlockKey{Height: revertHeight}, latestBlockKey, "Latest block key must be the revert height")
+
+		for _, blockSeed := range blockSeeds {
+			blockKey, err := txn.ReadBlockKey(testChainId, blockSeed.getBlockHash())
+			require.NoError(t, err, "ReadBlockKey must work")
+			if blockSeed.height > revertHeight {
+				require.Nil(t, blockKey, "Reverted block hash must be removed")
+			} else {
+				require.Equal(t, blockSeed.getBlockKey(), blockKey, "Kept block hash must stay")
+			}
+		}
+		for _, txSeed := range txSeeds {
+			txKey, err := txn.ReadTxKey(testChainId, txSeed.getTxHash())
+			require.NoError(t, err, "ReadTxKey must work")
+			if txSeed.height > revertHeight {
+				require.Nil(t, txKey, "Reverted tx hash must be removed")
+			} else {
+				require.Equal(t, txSeed.getTxKey(), txKey, "Kept tx hash must stay")
+			}
+		}
+		for _, logSeed := range logSeeds {
+			for _, key := range logScanEntryKeys(testChainId, logSeed.height, logSeed.txIndex, logSeed.logIndex, logSeed.getLogData()) {
+				_, err := txn.txn.Get(key)
+				if logSeed.height > revertHeight {
+					require.ErrorIs(t, err, badger.ErrKeyNotFound, "Reverted LogScanEntry must be removed")
+				} else {
+					require.NoError(t, err, "Kept LogScanEntry must stay")
+				}
+			}
+		}
+		return nil
+	}), "db.View must work")
+
+	removed, err = testDb.RevertToHeight(testChainId, revertHeight)
+	require.NoError(t, err, "Repeated RevertToHeight must work")
+	require.Empty(t, removed, "Repeated RevertToHeight must not remove anything")
+	require.EqualValues(t, 0, logger.getErrCnt(), "There should be no errors")
+}
+
+func TestVerify(t *testing.T) {
+	testDb, logger := initTestDb(t)
+	defer testDb.Close()
+
+	report, err := testDb.Verify(testChainId, false)
+	require.NoError(t, err, "Verify must work")
+	require.EqualValues(t, len(blockSeeds), report.Blocks, "Verify must walk all blocks")
+	require.EqualValues(t, len(txSeeds), report.Transactions, "Verify must walk all transactions")
+	require.EqualValues(t, len(logSeeds), report.Logs, "Verify must walk all logs")
+	require.EqualValues(t, 5, report.HeightGaps.Count, "Verify must report the height gaps")
+	require.Equal(t, "heights 102-102", report.HeightGaps.Samples[0], "Verify must report the height gaps")
+	require.Zero(t, report.MissingBlockKeys.Count, "There should be no missing block keys")
+	require.Zero(t, report.MissingTxKeys.Count, "There should be no missing tx keys")
+	require.Zero(t, report.MissingLogScanEntries.Count, "There should be no missing log scan entries")
+
+	logSeed := logSeeds[1]
+	logScanKeys := logScanEntryKeys(testChainId, logSeed.height, logSeed.txIndex, logSeed.logIndex, logSeed.getLogData())
+	require.NoError(t, testDb.core.Update(func(txn *badger.Txn) error {
+		require.NoError(t, txn.Delete(dbkey.BlockKeyByHash.Get(uint64(testChainId), blockSeeds[2].getBlockHash().Bytes())))
+		require.NoError(t, txn.Delete(dbkey.TxKeyByHash.Get(uint64(testChainId), txSeeds[3].getTxHash().Bytes())))
+		require.NoError(t, txn.Delete(logScanKeys[0]))
+		return txn.Delete(logScanKeys[len(logScanKeys)-1])
+	}), "Deleting indexes must work")
+
+	report, err = testDb.Verify(testChainId, false)
+	require.NoError(t, err, "Verify must work")
+	require.EqualValues(t, 1, report.MissingBlockKeys.Count, "Verify must report the missing block key")
+	require.EqualValues(t, 1, report.MissingTxKeys.Count, "Verify must report the missing tx key")
+	require.EqualValues(t, 2, report.MissingLogScanEntries.Count, "Verify must report the missing log scan entries")
+	require.Zero(t, report.MissingLogScanEntries.Repaired, "Verify must not repair unless asked")
+	require.False(t, report.Consistent(), "Report must not be consistent")
+
+	report, err = testDb.Verify(testChainId, true)
+	require.NoError(t, err, "Verify with repair must work")
+	require.EqualValues(t, 1, report.MissingBlockKeys.Repaired, "Verify must repair the missing block key")
+	require.EqualValues(t, 1, report.MissingTxKeys.Repaired, "Verify must repair the missing tx key")
+	require.EqualValues(t, 2, report.MissingLogScanEntries.Repaired, "Verify must repair the missing log scan entries")
+
+	report, err = testDb.Verify(testChainId, false)
+	require.NoError(t, err, "Verify must work")
+	for _, issue := range report.issues()[1:] {
+		require.Zero(t, issue.Count, "There should be no issue left after repair")
+	}
+	require.NoError(t, testDb.View(func(txn *ViewTxn) error {
+		blockKey, err := txn.ReadBlockKey(testChainId, blockSeeds[2].getBlockHash())
+		require.NoError(t, err, "ReadBlockKey must work")
+		require.Equal(t, blockSeeds[2].getBlockKey(), blockKey, "Repaired block key must be right")
+		txKey, err := txn.ReadTxKey(testChainId, txSeeds[3].getTxHash())
+		require.NoError(t, err, "ReadTxKey must work")
+		require.Equal(t, txSeeds[3].getTxKey(), txKey, "Repaired tx key must be right")
+		return nil
+	}), "db.View must work")
+	require.EqualValues(t, 0, logger.getErrCnt(), "There should be no errors")
+}
+
+func TestReindex(t *testing.T) {
+	testDb, logger := initTestDb(t)
+	defer testDb.Close()
+	chainId := uint64(testChainId)
+
+	logSeed := logSeeds[1]
+	logScanKeys := logScanEntryKeys(chainId, logSeed.height, logSeed.txIndex, logSeed.logIndex, logSeed.getLogData())
+	staleLogScanKey := append(dbkey.LogScan.Get(chainId), 0xff, 0xff)
+	require.NoError(t, testDb.core.Update(func(txn *badger.Txn) error {
+		require.NoError(t, txn.Set(staleLogScanKey, nil))
+		require.NoError(t, txn.Delete(logScanKeys[0]))
+		return txn.Delete(dbkey.BlockKeyByHash.Get(chainId, blockSeeds[2].getBlockHash().Bytes()))
+	}), "Corrupting indexes must work")
+
+	var progress []ReindexProgress
+	opts := ReindexOptions{
+		Workers:      2,
+		BatchHeights: 1_000_000,
+		Progress:     func(p ReindexProgress) { progress = append(progress, p) },
+	}
+	require.NoError(t, testDb.Reindex(testChainId, LogScanIndex, opts), "Reindex must work")
+	require.NotEmpty(t, progress, "Reindex must report progress")
+	last := progress[len(progress)-1]
+	require.False(t, last.Resumed, "Reindex must not be resumed")
+	require.Equal(t, last.ToHeight+1, last.Height, "Reindex must cover all heights")
+	require.EqualValues(t, len(logSeeds), last.Rows, "Reindex must process all logs")
+	require.NoError(t, testDb.Reindex(testChainId, BlockKeysIndex, opts), "Reindex must work")
+
+	report, err := testDb.Verify(testChainId, false)
+	require.NoError(t, err, "Verify must work")
+	for _, issue := range report.issues()[1:] {
+		require.Zero(t, issue.Count, "There should be no issue left after reindex")
+	}
+	require.NoError(t, testDb.View(func(txn *ViewTxn) error {
+		ok, err := txn.exists(staleLogScanKey)
+		require.NoError(t, err)
+		require.False(t, ok, "Reindex must drop the whole family")
+		return nil
+	}))
+
+	// interrupted rebuild, the heights below the stored state must be left as they are
+	txSeed := txSeeds[3]
+	stateKey := dbkey.ReindexState.Get(chainId, uint64(TxKeysIndex))
+	require.NoError(t, testDb.core.Update(func(txn *badger.Txn) error {
+		return txn.Delete(dbkey.TxKeyByHash.Get(chainId, txSeed.getTxHash().Bytes()))
+	}))
+	require.NoError(t, insertInstantly(testDb, stateKey, &dbt.BlockKey{Height: txSeed.height + 1}))
+	progress = nil
+	require.NoError(t, testDb.Reindex(testChainId, TxKeysIndex, opts), "Reindex must work")
+	for _, p := range progress {
+		require.True(t, p.Resumed, "Reindex must be resumed")
+	}
+
+	report, err = testDb.Verify(testChainId, false)
+	require.NoError(t, err, "Verify must work")
+	require.EqualValues(t, 1, report.MissingTxKeys.Count, "Resumed reindex must not drop or rebuild the done heights")
+	require.NoError(t, testDb.View(func(txn *ViewTxn) error {
+		ok, err := txn.exists(stateKey)
+		require.NoError(t, err)
+		require.False(t, ok, "Reindex state must be cleared when done")
+		return nil
+	}))
+	require.EqualValues(t, 0, logger.getErrCnt(), "There should be no errors")
+}
+
+func TestPrune(t *testing.T) {
+	testDb, logger := initTestDb(t)
+	defer testDb.Close()
+
+	n, err := testDb.PruneBelow(testChainId, 120)
+	require.NoError(t, err, "PruneBelow must work")
+	require.Equal(t, 4, n, "PruneBelow must delete the blocks below the height")
+
+	report, err := testDb.Verify(testChainId, false)
+	require.NoError(t, err, "Verify must work")
+	require.EqualValues(t, len(blockSeeds)-4, report.Blocks, "Pruned blocks must be deleted")
+	require.EqualValues(t, len(txSeeds)-9, report.Transactions, "Transactions of pruned blocks must be deleted")
+	for _, issue := range report.issues()[1:] {
+		require.Zero(t, issue.Count, "Pruning must leave the indexes consistent")
+	}
+	require.NoError(t, testDb.View(func(txn *ViewTxn) error {
+		pruned, err := txn.ReadPrunedHeight(testChainId)
+		require.NoError(t, err, "ReadPrunedHeight must work")
+		require.Equal(t, uint64(120), *pruned, "Pruned height must be recorded")
+		key, err := txn.ReadBlockKey(testChainId, blockSeeds[0].getBlockHash())
+		require.NoError(t, err, "ReadBlockKey must work")
+		require.Nil(t, key, "Block key of pruned block must be deleted")
+		txKey, err := txn.ReadTxKey(testChainId, txSeeds[0].getTxHash())
+		require.NoError(t, err, "ReadTxKey must work")
+		require.Nil(t, txKey, "Tx key of pruned transaction must be deleted")
+		block, err := txn.ReadBlock(testChainId, dbt.BlockKey{Height: blockSeeds[0].height}, false)
+		require.NoError(t, err, "ReadBlock must work")
+		require.Nil(t, block, "Pruned block must be deleted")
+		ok, err := txn.exists(logScanEntryKeys(uint64(testChainId), logSeeds[0].height, logSeeds[0].txIndex,
+			logSeeds[0].logIndex, logSeeds[0].getLogData())[0])
+		require.NoError(t, err)
+		require.False(t, ok, "Log scan entries of pruned logs must be deleted")
+		return nil
+	}))
+
+	prefixes := heightRangePrefixes(uint64(testChainId), 0, 0x10203, dbkey.BlockData)
+	require.Len(t, prefixes, 6, "Aligned height runs must share a prefix")
+	for _, height := range []uint64{0, 0xff, 0x100, 0xffff, 0x10000, 0x10202, 0x10203, 0x10300, 0x20000} {
+		key := dbkey.BlockData.Get(uint64(testChainId), height)
+		covered := false
+		for _, prefix := range prefixes {
+			covered = covered || bytes.HasPrefix(key, prefix)
+		}
+		require.Equal(t, height < 0x10203, covered, "Prefixes must cover exactly the heights below the cutoff")
+	}
+
+	testDb.retainBlocks = 3
+	n, err = testDb.Prune(time.Now())
+	require.NoError(t, err, "Prune must work")
+	require.Equal(t, 8, n, "Prune must keep the last retainBlocks heights")
+	n, err = testDb.Prune(time.Now())
+	require.NoError(t, err, "Prune must work")
+	require.Zero(t, n, "There should be nothing left to prune")
+
+	// retention by age, on a chain with known timestamps
+	now := time.Now()
+	ageChainId := uint64(testChainId + 2)
+	writer := testDb.NewWriter()
+	for height := uint64(1); height <= 5; height++ {
+		seed := &blockSeed{height: height}
+		data := seed.getBlockData()
+		data.Timestamp = uint64(now.Add(-time.Duration(60-10*height) * time.Minute).Unix())
+		require.NoError(t, writer.InsertBlock(ageChainId, height, seed.getBlockHash(), data))
+	}
+	require.NoError(t, writer.Flush())
+	testDb.retainBlocks = 0
+	testDb.retainMaxAgeMinutes = 25
+	require.NoError(t, testDb.View(func(txn *ViewTxn) error {
+		cutoff, err := txn.pruneCutoff(ageChainId, now)
+		require.NoError(t, err, "pruneCutoff must work")
+		require.Equal(t, uint64(4), *cutoff, "Blocks older than retainMaxAgeMinutes must be pruned")
+		return nil
+	}))
+	require.EqualValues(t, 0, logger.getErrCnt(), "There should be no errors")
+}
+
+func TestIndependentDBs(t *testing.T) {
+	newDB := func(options badger.Options) *DB {
+		db, err := NewDB(Config{BadgerConfig: options.WithLogger(testLogger{}), GcIntervalSeconds: 10}, codec.NewTinypackCodec())
+		require.NoError(t, err, "NewDB must work")
+		return db
+	}
+	hasBlock := func(db *DB, seed *blockSeed) bool {
+		var key *dbt.BlockKey
+		require.NoError(t, db.View(func(txn *ViewTxn) error {
+			var err error
+			key, err = txn.ReadBlockKey(testChainId, seed.getBlockHash())
+			return err
+		}))
+		return key != nil
+	}
+	insertBlock := func(db *DB, seed *blockSeed) {
+		w := db.NewWriter()
+		require.NoError(t, w.InsertBlock(testChainId, seed.height, seed.getBlockHash(), seed.getBlockData()))
+		require.NoError(t, w.Flush())
+	}
+
+	// in-memory DBs are never shared
+	a, b := newDB(badger.DefaultOptions("").WithInMemory(true)), newDB(badger.DefaultOptions("").WithInMemory(true))
+	insertBlock(a, &blockSeeds[0])
+	require.True(t, hasBlock(a, &blockSeeds[0]))
+	require.False(t, hasBlock(b, &blockSeeds[0]), "In-memory DBs must be independent")
+	require.NoError(t, a.Close())
+	require.NoError(t, a.Close(), "Closing twice must be a no-op")
+	insertBlock(b, &blockSeeds[1])
+	require.True(t, hasBlock(b, &blockSeeds[1]), "Closing a DB must not close the others")
+	require.NoError(t, b.Close())
+
+	// DBs on the same directory share the badger instance until the last one is closed
+	dir := t.TempDir()
+	c, d := newDB(badger.DefaultOptions(dir)), newDB(badger.DefaultOptions(dir))
+	e := newDB(badger.DefaultOptions(t.TempDir()))
+	insertBlock(c, &blockSeeds[2])
+	require.True(t, hasBlock(d, &blockSeeds[2]), "DBs on the same directory must be shared")
+	require.False(t, hasBlock(e, &blockSeeds[2]), "DBs on different directories must be independent")
+	require.NoError(t, c.Close())
+	require.True(t, hasBlock(d, &blockSeeds[2]), "Shared instance must be kept open while in use")
+	require.NoError(t, d.Close())
+	require.NoError(t, e.Close())
+
+	reopened := newDB(badger.DefaultOptions(dir))
+	defer reopened.Close()
+	require.True(t, hasBlock(reopened, &blockSeeds[2]), "Closed directory must be reopened")
+}
+
+func TestExpiredFilters(t *testing.T) {
+	testDb, _ := initTestDb(t)
+	defer testDb.Close()
+
+	now := time.Now()
+	stale := uint64(now.Add(-time.Hour).UnixNano())
+	fresh := uint64(now.Add(-time.Minute).UnixNano())
+
+	staleId, freshId := genHash(1, 1), genHash(1, 2)
+	require.NoError(t, testDb.InsertBlockFilter(testChainId, staleId, &dbt.BlockFilter{CreatedAt: stale, LastPolledAt: stale}))
+	require.NoError(t, testDb.InsertLogFilter(testChainId, freshId, &dbt.LogFilter{CreatedAt: stale, LastPolledAt: fresh}))
+	require.NoError(t, testDb.InsertTransactionFilter(testChainId+1, staleId, &dbt.TransactionFilter{CreatedAt: stale, LastPolledAt: stale}))
+
+	require.NoError(t, testDb.View(func(txn *ViewTxn) error {
+		bf, err := txn.ReadBlockFilter(testChainId, staleId)
+		require.NoError(t, err, "ReadBlockFilter must work")
+		require.Nil(t, bf, "Expired filter must not be returned")
+		lf, err := txn.ReadLogFilter(testChainId, freshId)
+		require.NoError(t, err, "ReadLogFilter must work")
+		require.NotNil(t, lf, "Recently polled filter must be returned")
+		return nil
+	}))
+
+	n, err := testDb.DeleteExpiredFilters(now)
+	require.NoError(t, err, "DeleteExpiredFilters must work")
+	require.Equal(t, 2, n, "Expired filters of all chains must be deleted")
+
+	require.NoError(t, testDb.View(func(txn *ViewTxn) error {
+		_, err := txn.txn.Get(dbkey.BlockFilter.Get(uint64(testChainId), staleId.Bytes()))
+		require.ErrorIs(t, err, badger.ErrKeyNotFound, "Expired filter must be deleted")
+		_, err = txn.txn.Get(dbkey.TxFilter.Get(uint64(testChainId+1), staleId.Bytes()))
+		require.ErrorIs(t, err, badger.ErrKeyNotFound, "Expired filter must be deleted")
+		_, err = txn.txn.Get(dbkey.LogFilter.Get(uint64(testChainId), freshId.Bytes()))
+		require.NoError(t, err, "Recently polled filter must be kept")
+		return nil
+	}))
+}
+
+// legacyBlockFilter and legacyLogFilter are the filter layouts stored before LastPolledAt was introduced
+type legacyBlockFilter struct {
+	CreatedAt uint64
+	Metadata  primitives.VarData
+	From      dbt.BlockKey
+	Next      dbt.BlockKey
+	To        dbt.BlockKey
+}
+
+func (f *legacyBlockFilter) GetTinyPackChildrenPointers() ([]any, error) {
+	return []any{&f.CreatedAt, &f.Metadata, &f.From, &f.Next, &f.To}, nil
+}
+
+type legacyLogFilter struct {
+	CreatedAt uint64
+	Metadata  primitives.VarData
+	From      dbt.LogKey
+	Next      dbt.LogKey
+	To        dbt.LogKey
+	Addresses tinypack.VarList[primitives.Data20]
+	Topics    tinypack.VarList[tinypack.VarList[primitives.Data32]]
+}
+
+func (f *legacyLogFilter) GetTinyPackChildrenPointers() ([]any, error) {
+	return []any{&f.CreatedAt, &f.Metadata, &f.From, &f.Next, &f.To, &f.Addresses, &f.Topics}, nil
+}
+
+func TestLegacyFilters(t *testing.T) {
+	testDb, _ := initTestDb(t)
+	defer testDb.Close()
+
+	createdAt := uint64(time.Date(2025, 10, 9, 0, 0, 0, 0, time.UTC).UnixNano())
+	blockId, logId := genHash(2, 1), genHash(2, 2)
+	// the metadata length makes the encoding end on a word boundary, so that the current layout can't be decoded
+	legacyBlock := &legacyBlockFilter{
+		CreatedAt: createdAt,
+		Metadata:  primitives.VarDataFromBytes([]byte{1, 2, 3}),
+		From:      dbt.BlockKey{Height: 100},
+		Next:      dbt.BlockKey{Height: 105},
+		To:        dbt.BlockKey{Height: 1 << 62},
+	}
+	legacyLog := &legacyLogFilter{
+		CreatedAt: createdAt,
+		From:      dbt.LogKey{BlockHeight: 100},
+		Next:      dbt.LogKey{BlockHeight: 105, TransactionIndex: 2, LogIndex: 1},
+		To:        dbt.LogKey{BlockHeight: 1 << 62},
+		Addresses: tinypack.CreateVarList(genAddress(2, 3)),
+		Topics:    tinypack.CreateVarList(tinypack.CreateVarList(genHash(2, 4))),
+	}
+	require.NoError(t, insertInstantly(testDb, dbkey.BlockFilter.Get(uint64(testChainId), blockId.Bytes()), legacyBlock))
+	require.NoError(t, insertInstantly(testDb, dbkey.LogFilter.Get(uint64(testChainId), logId.Bytes()), legacyLog))
+
+	checkFilters := func() {
+		require.NoError(t, testDb.View(func(txn *ViewTxn) error {
+			bf, err := txn.ReadBlockFilter(testChainId, blockId)
+			require.NoError(t, err, "ReadBlockFilter must decode legacy filter")
+			require.NotNil(t, bf, "Legacy filter must be considered as polled just now")
+			require.Equal(t, legacyBlock.Next, bf.Next, "Legacy filter must be decoded correctly")
+			lf, err := txn.ReadLogFilter(testChainId, logId)
+			require.NoError(t, err, "ReadLogFilter must decode legacy filter")
+			require.NotNil(t, lf, "Legacy filter must be considered as polled just now")
+			require.Equal(t, legacyLog.Next, lf.Next, "Legacy filter must be decoded correctly")
+			require.Equal(t, legacyLog.Topics, lf.Topics, "Legacy filter must be decoded correctly")
+			return nil
+		}))
+	}
+	checkFilters()
+
+	now := time.Now()
+	n, err := testDb.DeleteExpiredFilters(now)
+	require.NoError(t, err, "DeleteExpiredFilters must work")
+	require.Equal(t, 0, n, "Legacy filters must not be deleted")
+	checkFilters()
+
+	require.NoError(t, testDb.View(func(txn *ViewTxn) error {
+		bf, err := read[dbt.BlockFilter](txn, dbkey.BlockFilter.Get(uint64(testChainId), blockId.Bytes()))
+		require.NoError(t, err, "Legacy filter must be rewritten in the current layout")
+		require.Equal(t, uint64(now.UnixNano()), bf.LastPolledAt, "Legacy filter must be marked as polled at GC time")
+		return nil
+	}))
+
+	n, err = testDb.DeleteExpiredFilters(now.Add(time.Hour))
+	require.NoError(t, err, "DeleteExpiredFilters must work")
+	require.Equal(t, 2, n, "Migrated legacy filters must expire after TTL")
+}
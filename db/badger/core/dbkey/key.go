@@ -26,6 +26,7 @@ var (
 	logScanMask = dbs.Var(1)
 	logScanHash = dbs.Var(logscan.HashSize)
 	filterId    = dbs.Var(32)
+	indexFamily = dbs.Var(1)
 )
 
 var (
@@ -63,4 +64,7 @@ var (
 	LogFilters       = dbs.Path(dbs.Const(0), chainId, dbs.Const(8), dbs.Const(2))
 	LogFilter        = dbs.Path(dbs.Const(0), chainId, dbs.Const(8), dbs.Const(2), filterId)
 	IndexerState     = dbs.Path(dbs.Const(0), chainId, dbs.Const(9))
+	ReindexStates    = dbs.Path(dbs.Const(0), chainId, dbs.Const(10))
+	ReindexState     = dbs.Path(dbs.Const(0), chainId, dbs.Const(10), indexFamily)
+	PrunedHeight     = dbs.Path(dbs.Const(0), chainId, dbs.Const(11))
 )
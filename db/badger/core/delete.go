@@ -1,8 +1,14 @@
 package core
 
 import (
+	"math"
+	"time"
+
 	"github.com/aurora-is-near/relayer2-base/db/badger/core/dbkey"
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
 	dbp "github.com/aurora-is-near/relayer2-base/types/primitives"
+
+	"github.com/dgraph-io/badger/v3"
 )
 
 func (txn *ViewTxn) DeleteFilter(chainId uint64, filterId dbp.Data32) error {
@@ -20,3 +26,129 @@ func (txn *ViewTxn) DeleteTransactionFilter(chainId uint64, filterId dbp.Data32)
 func (txn *ViewTxn) DeleteLogFilter(chainId uint64, filterId dbp.Data32) error {
 	return txn.txn.Delete(dbkey.LogFilter.Get(chainId, filterId.Bytes()))
 }
+
+// DeleteExpiredFilters deletes the filters of all chains which have not been polled for longer than the filter TTL,
+// returns the number of deleted filters. The filters stored before LastPolledAt was introduced are rewritten as polled
+// at now, so that they expire after the TTL as well.
+//
+// The filters of every chain are swept in a single transaction, so a filter stored concurrently by a poll makes the
+// sweep fail with a conflict instead of being overwritten or deleted, it is swept again on the next run.
+func (db *DB) DeleteExpiredFilters(now time.Time) (int, error) {
+	ttl := db.filterTtl()
+	if ttl <= 0 {
+		return 0, nil
+	}
+
+	var chainIds []uint64
+	err := db.View(func(txn *ViewTxn) error {
+		var err error
+		chainIds, err = txn.readChainIds()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, chainId := range chainIds {
+		n := 0
+		err := db.Update(func(txn *ViewTxn) error {
+			for _, sweep := range []func(*ViewTxn, uint64, time.Time, time.Duration) (int, error){
+				sweepFilters[dbt.BlockFilter],
+				sweepFilters[dbt.TransactionFilter],
+				sweepFilters[dbt.LogFilter],
+			} {
+				deleted, err := sweep(txn, chainId, now, ttl)
+				if err != nil {
+					return err
+				}
+				n += deleted
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// sweepFilters deletes the expired filters of type T through txn, the filters which can't be decoded are considered
+// expired too. The filters stored before LastPolledAt was introduced are rewritten as polled at now. Returns the
+// number of deleted filters.
+func sweepFilters[T any, PT interface {
+	*T
+	expirable
+}](txn *ViewTxn, chainId uint64, now time.Time, ttl time.Duration) (int, error) {
+	var prefix []byte
+	switch any(PT(nil)).(type) {
+	case *dbt.BlockFilter:
+		prefix = dbkey.BlockFilters.Get(chainId)
+	case *dbt.TransactionFilter:
+		prefix = dbkey.TxFilters.Get(chainId)
+	case *dbt.LogFilter:
+		prefix = dbkey.LogFilters.Get(chainId)
+	}
+
+	var expired [][]byte
+	legacy := make(map[string][]byte)
+	err := txn.iterateKeys(prefix, true, func(item *badger.Item) error {
+		var filter *T
+		err := item.Value(func(val []byte) (err error) {
+			filter, err = decodeFilter[T, PT](txn.db, val)
+			return err
+		})
+		if err != nil || PT(filter).Expired(now, ttl) {
+			expired = append(expired, item.KeyCopy(nil))
+			return nil
+		}
+		if touchLegacyFilter(filter, now) {
+			value, err := txn.db.codec.Marshal(filter)
+			if err != nil {
+				return err
+			}
+			legacy[string(item.Key())] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range expired {
+		if err := txn.txn.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	for key, value := range legacy {
+		if err := txn.txn.Set([]byte(key), value); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
+
+// readChainIds returns the ids of all chains having any record in the DB
+func (txn *ViewTxn) readChainIds() ([]uint64, error) {
+	it := txn.txn.NewIterator(badger.IteratorOptions{
+		Prefix: dbkey.Chains.Get(),
+	})
+	defer it.Close()
+
+	var chainIds []uint64
+	for it.Rewind(); it.Valid(); {
+		if len(it.Item().Key()) < len(dbkey.Chain.Get(uint64(0))) {
+			it.Next()
+			continue
+		}
+		chainId := dbkey.Chain.ReadUintVar(it.Item().Key(), 0)
+		chainIds = append(chainIds, chainId)
+		if chainId == math.MaxUint64 {
+			break
+		}
+		// skip the rest of the chain records
+		it.Seek(dbkey.Chain.Get(chainId + 1))
+	}
+	return chainIds, nil
+}
@@ -92,6 +92,17 @@ func (w *Writer) InsertLog(chainId, height, txIndex, logIndex uint64, data *dbt.
 		return err
 	}
 
+	for _, key := range logScanEntryKeys(chainId, height, txIndex, logIndex, data) {
+		if err := w.writer.Set(key, nil); err != nil {
+			w.db.logger.Errorf("DB: Can't insert LogScanEntry: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// logScanEntryKeys returns the keys of the LogScanEntry rows indexing the given log, one per scan bitmask
+func logScanEntryKeys(chainId, height, txIndex, logIndex uint64, data *dbt.Log) [][]byte {
 	scanFeatures := make([][]byte, len(data.Topics.Content)+1)
 	scanFeatures[0] = data.Address.Bytes()
 	for i, t := range data.Topics.Content {
@@ -99,15 +110,12 @@ func (w *Writer) InsertLog(chainId, height, txIndex, logIndex uint64, data *dbt.
 	}
 
 	maxScanBitmask := 1<<len(scanFeatures) - 1
+	keys := make([][]byte, 0, maxScanBitmask)
 	for scanBitmask := 1; scanBitmask <= maxScanBitmask; scanBitmask++ {
 		hash := logscan.CalcHash(scanFeatures, scanBitmask)
-		err := w.writer.Set(dbkey.LogScanEntry.Get(chainId, uint64(scanBitmask), hash, height, txIndex, logIndex), nil)
-		if err != nil {
-			w.db.logger.Errorf("DB: Can't insert LogScanEntry: %v", err)
-			return err
-		}
+		keys = append(keys, dbkey.LogScanEntry.Get(chainId, uint64(scanBitmask), hash, height, txIndex, logIndex))
 	}
-	return nil
+	return keys
 }
 
 func (db *DB) InsertIndexerState(chainId uint64, data []byte) error {
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/tinypack"
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+)
+
+// legacyFilter decodes the filters written before LastPolledAt was appended to their layout
+type legacyFilter struct {
+	filter tinypack.Composite
+}
+
+func (f *legacyFilter) GetTinyPackChildrenPointers() ([]any, error) {
+	children, err := f.filter.GetTinyPackChildrenPointers()
+	if err != nil {
+		return nil, err
+	}
+	// LastPolledAt is always the last field
+	return children[:len(children)-1], nil
+}
+
+// decodeFilter decodes the filter stored in val, falling back to the layout without LastPolledAt
+func decodeFilter[T any, PT interface {
+	*T
+	tinypack.Composite
+}](db *DB, val []byte) (*T, error) {
+	filter := new(T)
+	err := db.codec.Unmarshal(val, filter)
+	if err == nil {
+		return filter, nil
+	}
+	legacy := new(T)
+	if db.codec.Unmarshal(val, &legacyFilter{filter: PT(legacy)}) == nil {
+		return legacy, nil
+	}
+	return nil, err
+}
+
+// lastPolledAt returns a pointer to the LastPolledAt field of the given filter
+func lastPolledAt(filter any) *uint64 {
+	switch f := filter.(type) {
+	case *dbt.BlockFilter:
+		return &f.LastPolledAt
+	case *dbt.TransactionFilter:
+		return &f.LastPolledAt
+	case *dbt.LogFilter:
+		return &f.LastPolledAt
+	}
+	return nil
+}
+
+// touchLegacyFilter sets LastPolledAt of the filter if it was stored before LastPolledAt was introduced, returns
+// false if the filter already has it
+func touchLegacyFilter(filter any, now time.Time) bool {
+	polledAt := lastPolledAt(filter)
+	if polledAt == nil || *polledAt != 0 {
+		return false
+	}
+	*polledAt = uint64(now.UnixNano())
+	return true
+}
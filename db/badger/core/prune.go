@@ -0,0 +1,229 @@
+package core
+
+import (
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/db/badger/core/dbkey"
+	dbs "github.com/aurora-is-near/relayer2-base/db/badger/core/dbkey/dbschema"
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// pruneBatchBlocks limits the number of blocks deleted through a single WriteBatch
+const pruneBatchBlocks = 1000
+
+// ReadPrunedHeight returns the height below which the blocks of the chain have been pruned, nil if the chain has never
+// been pruned
+func (txn *ViewTxn) ReadPrunedHeight(chainId uint64) (*uint64, error) {
+	key, err := read[dbt.BlockKey](txn, dbkey.PrunedHeight.Get(chainId))
+	if err != nil || key == nil {
+		return nil, err
+	}
+	return &key.Height, nil
+}
+
+// Prune deletes the blocks of all chains which are out of the retention window given by the retainBlocks and
+// retainMaxAgeMinutes settings, returns the number of deleted blocks
+func (db *DB) Prune(now time.Time) (int, error) {
+	cutoffs := make(map[uint64]uint64)
+	err := db.View(func(txn *ViewTxn) error {
+		chainIds, err := txn.readChainIds()
+		if err != nil {
+			return err
+		}
+		for _, chainId := range chainIds {
+			cutoff, err := txn.pruneCutoff(chainId, now)
+			if err != nil {
+				return err
+			}
+			if cutoff != nil {
+				cutoffs[chainId] = *cutoff
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.logger.Errorf("DB: Can't compute the pruning heights: %v", err)
+		return 0, err
+	}
+
+	total := 0
+	for chainId, cutoff := range cutoffs {
+		n, err := db.PruneBelow(chainId, cutoff)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// pruneCutoff returns the lowest height to retain according to the retention policy, nil if there is nothing new to
+// prune. The latest block is always retained.
+func (txn *ViewTxn) pruneCutoff(chainId uint64, now time.Time) (*uint64, error) {
+	latest, err := txn.ReadLatestBlockKey(chainId)
+	if err != nil || latest == nil {
+		return nil, err
+	}
+
+	var cutoff uint64
+	if txn.db.retainBlocks > 0 && latest.Height >= txn.db.retainBlocks {
+		cutoff = latest.Height - txn.db.retainBlocks + 1
+	}
+	if txn.db.retainMaxAgeMinutes > 0 {
+		minTimestamp := uint64(now.Add(-time.Duration(txn.db.retainMaxAgeMinutes) * time.Minute).Unix())
+		it := txn.txn.NewIterator(badger.IteratorOptions{
+			Prefix:         dbkey.BlocksData.Get(chainId),
+			PrefetchValues: true,
+		})
+		defer it.Close()
+		// blocks below the cutoff are pruned on the previous runs, so only the blocks to be pruned now are read
+		for it.Seek(dbkey.BlockData.Get(chainId, cutoff)); it.Valid(); it.Next() {
+			height := dbkey.BlockData.ReadUintVar(it.Item().Key(), 1)
+			if height >= latest.Height {
+				break
+			}
+			block, err := readItem[dbt.Block](txn.db, it.Item())
+			if err != nil {
+				return nil, err
+			}
+			if block.Timestamp >= minTimestamp {
+				break
+			}
+			cutoff = height + 1
+		}
+	}
+
+	pruned, err := txn.ReadPrunedHeight(chainId)
+	if err != nil {
+		return nil, err
+	}
+	if cutoff == 0 || (pruned != nil && *pruned >= cutoff) {
+		return nil, nil
+	}
+	return &cutoff, nil
+}
+
+// PruneBelow deletes every block below the given height together with its transactions, logs and all their indexes,
+// returns the number of deleted blocks.
+//
+// The height is recorded before deleting anything, so that reads below it fail with a pruned error rather than
+// returning partially deleted blocks. The hash indexes are deleted as well, so the reads by hash can't tell the pruned
+// blocks and transactions from the unknown ones once the pruning is done. Unlike RevertToHeight the deletion isn't
+// atomic: the hash indexes and log scan entries are deleted through WriteBatches of pruneBatchBlocks blocks, then the
+// rows keyed by the height are dropped by prefix ranges.
+func (db *DB) PruneBelow(chainId, height uint64) (int, error) {
+	var pruned, first *uint64
+	err := db.View(func(txn *ViewTxn) error {
+		var err error
+		pruned, err = txn.ReadPrunedHeight(chainId)
+		first = txn.readNextHeight(chainId, 0)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if pruned == nil || *pruned < height {
+		if err := insertInstantly(db, dbkey.PrunedHeight.Get(chainId), &dbt.BlockKey{Height: height}); err != nil {
+			return 0, err
+		}
+	}
+	if first == nil || *first >= height {
+		return 0, nil
+	}
+
+	total := 0
+	for from, done := *first, false; !done; {
+		var n int
+		n, from, done, err = db.pruneBatch(chainId, height, from)
+		total += n
+		if err != nil {
+			db.logger.Errorf("DB: Can't prune blocks below height %d: %v", height, err)
+			return 0, err
+		}
+	}
+	if err := db.core.DropPrefix(heightRangePrefixes(chainId, *first, height, prunedFamilies...)...); err != nil {
+		db.logger.Errorf("DB: Can't prune blocks below height %d: %v", height, err)
+		return 0, err
+	}
+	return total, nil
+}
+
+// prunedFamilies are the key families keyed by the block height first, which are pruned by prefix ranges
+var prunedFamilies = []*dbs.SchemaPath{
+	dbkey.BlockHash, dbkey.BlockData, dbkey.TxHashesForBlock, dbkey.TxsDataForBlock, dbkey.LogsForBlock,
+}
+
+// heightRangePrefixes returns the key prefixes of the given families covering the heights from the given one and below
+// the cutoff. The heights are big endian, so every run of heights aligned to a power of 256 shares a prefix.
+func heightRangePrefixes(chainId, from, cutoff uint64, families ...*dbs.SchemaPath) [][]byte {
+	if cutoff > dbkey.MaxBlockHeight+1 {
+		cutoff = dbkey.MaxBlockHeight + 1
+	}
+	var prefixes [][]byte
+	for from < cutoff {
+		span, trimmed := uint64(1), 0
+		for span <= dbkey.MaxBlockHeight && from%(span<<8) == 0 && from+(span<<8) <= cutoff {
+			span <<= 8
+			trimmed++
+		}
+		for _, family := range families {
+			key := family.Get(chainId, from)
+			prefixes = append(prefixes, key[:len(key)-trimmed])
+		}
+		from += span
+	}
+	return prefixes
+}
+
+// pruneBatch deletes the hash indexes and log scan entries of up to pruneBatchBlocks blocks from the given height and
+// below the cutoff height in a single WriteBatch, returns the number of blocks, the height to continue from and whether
+// there is anything left
+func (db *DB) pruneBatch(chainId, cutoff, from uint64) (int, uint64, bool, error) {
+	w := db.NewWriter()
+	defer w.Cancel()
+
+	n, done := 0, false
+	err := db.View(func(txn *ViewTxn) error {
+		for n < pruneBatchBlocks {
+			next := txn.readNextHeight(chainId, from)
+			if next == nil || *next >= cutoff {
+				done = true
+				return nil
+			}
+			if _, err := txn.deleteBlock(w.writer, chainId, *next, false); err != nil {
+				return err
+			}
+			from = *next + 1
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, from, false, err
+	}
+	if err := w.Flush(); err != nil {
+		return 0, from, false, err
+	}
+	return n, from, done, nil
+}
+
+// readNextHeight returns the lowest height, starting from the given one, having any block, transaction or log record
+func (txn *ViewTxn) readNextHeight(chainId, from uint64) *uint64 {
+	var next *uint64
+	for _, family := range indexFamilies {
+		it := txn.txn.NewIterator(badger.IteratorOptions{
+			Prefix: family.primary.Get(chainId),
+		})
+		it.Seek(family.primaryForBlock.Get(chainId, from))
+		if it.Valid() {
+			height := family.primaryForBlock.ReadUintVar(it.Item().Key(), 1)
+			if next == nil || height < *next {
+				next = &height
+			}
+		}
+		it.Close()
+	}
+	return next
+}
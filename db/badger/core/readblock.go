@@ -5,8 +5,10 @@ import (
 	"context"
 	"fmt"
 	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+	"github.com/aurora-is-near/relayer2-base/types/indexer"
 	"github.com/aurora-is-near/relayer2-base/types/primitives"
 	"github.com/aurora-is-near/relayer2-base/types/response"
+	"github.com/aurora-is-near/relayer2-base/utils"
 
 	"github.com/aurora-is-near/relayer2-base/db/badger/core/dbkey"
 	"github.com/dgraph-io/badger/v3"
@@ -87,6 +89,75 @@ func (txn *ViewTxn) ReadBlock(chainId uint64, key dbt.BlockKey, fullTransactions
 	return makeBlockResponse(height, *hash, *data, txs), nil
 }
 
+// ReadIndexerBlock reads the block at the given key along with its transactions and logs in the shape they were
+// inserted, returns nil if there is no such block
+func (txn *ViewTxn) ReadIndexerBlock(chainId uint64, key dbt.BlockKey) (*indexer.Block, error) {
+	hash, err := readCached[primitives.Data32](txn, dbkey.BlockHash.Get(chainId, key.Height))
+	if err != nil || hash == nil {
+		return nil, err
+	}
+	data, err := read[dbt.Block](txn, dbkey.BlockData.Get(chainId, key.Height))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	block := utils.DbBlockToIndexerBlock(chainId, key.Height, *hash, data)
+
+	txHashes := make(map[uint64]primitives.Data32)
+	err = txn.iterateKeys(dbkey.TxHashesForBlock.Get(chainId, key.Height), true, func(item *badger.Item) error {
+		txHash, err := readItem[primitives.Data32](txn.db, item)
+		if err != nil || txHash == nil {
+			return err
+		}
+		txHashes[dbkey.TxHash.ReadUintVar(item.Key(), 2)] = *txHash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make(map[uint64]*indexer.Transaction)
+	err = txn.iterateKeys(dbkey.TxsDataForBlock.Get(chainId, key.Height), true, func(item *badger.Item) error {
+		txIndex := dbkey.TxData.ReadUintVar(item.Key(), 2)
+		txHash, ok := txHashes[txIndex]
+		if !ok {
+			txn.db.logger.Errorf("DB: found dangling TxData, will ignore [key=%v]", item.Key())
+			return nil
+		}
+		txData, err := readItem[dbt.Transaction](txn.db, item)
+		if err != nil || txData == nil {
+			return err
+		}
+		tx := utils.DbTxnToIndexerTxn(chainId, key.Height, txIndex, *hash, txHash, txData)
+		txs[txIndex] = tx
+		block.Transactions = append(block.Transactions, tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = txn.iterateKeys(dbkey.LogsForBlock.Get(chainId, key.Height), true, func(item *badger.Item) error {
+		txIndex := dbkey.Log.ReadUintVar(item.Key(), 2)
+		tx, ok := txs[txIndex]
+		if !ok {
+			txn.db.logger.Errorf("DB: found dangling Log, will ignore [key=%v]", item.Key())
+			return nil
+		}
+		data, err := readItem[dbt.Log](txn.db, item)
+		if err != nil || data == nil {
+			return err
+		}
+		tx.Logs = append(tx.Logs, utils.DbLogToIndexerLog(data))
+		return nil
+	})
+	if err != nil {
+		errCtx := fmt.Sprintf("chainId=%v, block=%v", chainId, key.Height)
+		txn.db.logger.Errorf("DB: errors reading indexer block for %v: %v", errCtx, err)
+		return nil, err
+	}
+	return block, nil
+}
+
 func (txn *ViewTxn) ReadBlockTxCount(chainId uint64, key dbt.BlockKey) (primitives.HexUint, error) {
 	it := txn.txn.NewIterator(badger.IteratorOptions{
 		Reverse: true,
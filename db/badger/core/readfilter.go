@@ -1,21 +1,26 @@
 package core
 
 import (
+	"time"
+
 	"github.com/aurora-is-near/relayer2-base/db/badger/core/dbkey"
+	"github.com/aurora-is-near/relayer2-base/tinypack"
 	"github.com/aurora-is-near/relayer2-base/types/db"
 	dbp "github.com/aurora-is-near/relayer2-base/types/primitives"
+
+	"github.com/dgraph-io/badger/v3"
 )
 
 func (txn *ViewTxn) ReadBlockFilter(chainId uint64, filterId dbp.Data32) (*db.BlockFilter, error) {
-	return read[db.BlockFilter](txn, dbkey.BlockFilter.Get(chainId, filterId.Bytes()))
+	return readFilter[db.BlockFilter](txn, dbkey.BlockFilter.Get(chainId, filterId.Bytes()))
 }
 
 func (txn *ViewTxn) ReadTransactionFilter(chainId uint64, filterId dbp.Data32) (*db.TransactionFilter, error) {
-	return read[db.TransactionFilter](txn, dbkey.TxFilter.Get(chainId, filterId.Bytes()))
+	return readFilter[db.TransactionFilter](txn, dbkey.TxFilter.Get(chainId, filterId.Bytes()))
 }
 
 func (txn *ViewTxn) ReadLogFilter(chainId uint64, filterId dbp.Data32) (*db.LogFilter, error) {
-	return read[db.LogFilter](txn, dbkey.LogFilter.Get(chainId, filterId.Bytes()))
+	return readFilter[db.LogFilter](txn, dbkey.LogFilter.Get(chainId, filterId.Bytes()))
 }
 
 func (txn *ViewTxn) ReadFilter(chainId uint64, filterId dbp.Data32) (any, error) {
@@ -31,3 +36,36 @@ func (txn *ViewTxn) ReadFilter(chainId uint64, filterId dbp.Data32) (any, error)
 	}
 	return nil, err
 }
+
+type expirable interface {
+	tinypack.Composite
+	Expired(now time.Time, ttl time.Duration) bool
+}
+
+// readFilter reads the filter stored under key, expired filters are reported as missing even if not swept yet
+func readFilter[T any, PT interface {
+	*T
+	expirable
+}](txn *ViewTxn, key []byte) (*T, error) {
+	item, err := txn.txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		txn.db.logger.Errorf("DB: Can't fetch item for key %v: %v", key, err)
+		return nil, err
+	}
+	var filter *T
+	err = item.Value(func(val []byte) error {
+		filter, err = decodeFilter[T, PT](txn.db, val)
+		return err
+	})
+	if err != nil {
+		txn.db.logger.Errorf("DB: can't read filter: %v", err)
+		return nil, err
+	}
+	if PT(filter).Expired(time.Now(), txn.db.filterTtl()) {
+		return nil, nil
+	}
+	return filter, nil
+}
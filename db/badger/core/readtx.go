@@ -262,3 +262,67 @@ func (txn *ViewTxn) ReadTransactions(
 	}
 	return response, to, nil
 }
+
+// ReadBlockReceipts returns the receipts of all the transactions of the block in transaction index order. The tx
+// hashes, tx data and logs of a block are stored contiguously, so each of them is read with a single iterator pass.
+// Returns nil if the block does not exist.
+func (txn *ViewTxn) ReadBlockReceipts(chainId uint64, key dbt.BlockKey) ([]*response.TransactionReceipt, error) {
+	blockHash, err := readCached[primitives.Data32](txn, dbkey.BlockHash.Get(chainId, key.Height))
+	if err != nil || blockHash == nil {
+		return nil, err
+	}
+
+	txHashes := make(map[uint64]primitives.Data32)
+	err = txn.iterateKeys(dbkey.TxHashesForBlock.Get(chainId, key.Height), true, func(item *badger.Item) error {
+		txHash, err := readItem[primitives.Data32](txn.db, item)
+		if err != nil || txHash == nil {
+			return err
+		}
+		txHashes[dbkey.TxHash.ReadUintVar(item.Key(), 2)] = *txHash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make(map[uint64][]*response.Log)
+	err = txn.iterateKeys(dbkey.LogsForBlock.Get(chainId, key.Height), true, func(item *badger.Item) error {
+		data, err := readItem[dbt.Log](txn.db, item)
+		if err != nil || data == nil {
+			return err
+		}
+		txIndex := dbkey.Log.ReadUintVar(item.Key(), 2)
+		logIndex := dbkey.Log.ReadUintVar(item.Key(), 3)
+		logs[txIndex] = append(logs[txIndex], makeLogResponse(key.Height, txIndex, logIndex, *blockHash, txHashes[txIndex], data))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := []*response.TransactionReceipt{}
+	err = txn.iterateKeys(dbkey.TxsDataForBlock.Get(chainId, key.Height), true, func(item *badger.Item) error {
+		txIndex := dbkey.TxData.ReadUintVar(item.Key(), 2)
+		txHash, ok := txHashes[txIndex]
+		if !ok {
+			txn.db.logger.Errorf("DB: found dangling TxData, will ignore [key=%v]", item.Key())
+			return nil
+		}
+		txData, err := readItem[dbt.Transaction](txn.db, item)
+		if err != nil || txData == nil {
+			return err
+		}
+		txLogs, ok := logs[txIndex]
+		if !ok {
+			txLogs = []*response.Log{}
+		}
+		receipts = append(receipts, makeTransactionReceiptResponse(key.Height, txIndex, *blockHash, txHash, txData, txLogs))
+		return nil
+	})
+	if err != nil {
+		errCtx := fmt.Sprintf("chainId=%v, block=%v", chainId, key.Height)
+		txn.db.logger.Errorf("DB: errors reading receipts for %v: %v", errCtx, err)
+		return nil, err
+	}
+	return receipts, nil
+}
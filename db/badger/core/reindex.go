@@ -0,0 +1,317 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aurora-is-near/relayer2-base/db/badger/core/dbkey"
+	dbs "github.com/aurora-is-near/relayer2-base/db/badger/core/dbkey/dbschema"
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+
+	"github.com/dgraph-io/badger/v3"
+	"golang.org/x/sync/errgroup"
+)
+
+const defaultReindexBatchHeights = 10_000
+
+// IndexFamily identifies a family of secondary keys which are derived from the primary block, transaction and log rows
+type IndexFamily byte
+
+const (
+	// BlockKeysIndex is the block height by hash lookup (dbkey.BlockKeyByHash), rebuilt from dbkey.BlockHash
+	BlockKeysIndex IndexFamily = iota
+	// TxKeysIndex is the transaction key by hash lookup (dbkey.TxKeyByHash), rebuilt from dbkey.TxHash
+	TxKeysIndex
+	// LogScanIndex is the log search index (dbkey.LogScanEntry), rebuilt from dbkey.Log
+	LogScanIndex
+)
+
+type indexFamily struct {
+	name string
+	// derived is the key family dropped before the rebuild
+	derived *dbs.SchemaPath
+	// primary is the key family the index is rebuilt from, primaryForBlock is its sub-path for a given height
+	primary         *dbs.SchemaPath
+	primaryForBlock *dbs.SchemaPath
+	heights         func(txn *ViewTxn, chainId uint64) (from, to *uint64, err error)
+	rebuild         func(w *Writer, chainId, height uint64, item *badger.Item) error
+}
+
+var indexFamilies = map[IndexFamily]*indexFamily{
+	BlockKeysIndex: {
+		name:            "blockkeys",
+		derived:         dbkey.BlockKeysByHash,
+		primary:         dbkey.BlockHashes,
+		primaryForBlock: dbkey.BlockHash,
+		heights: func(txn *ViewTxn, chainId uint64) (*uint64, *uint64, error) {
+			earliest, err := txn.ReadEarliestBlockKey(chainId)
+			if err != nil || earliest == nil {
+				return nil, nil, err
+			}
+			latest, err := txn.ReadLatestBlockKey(chainId)
+			if err != nil || latest == nil {
+				return nil, nil, err
+			}
+			return &earliest.Height, &latest.Height, nil
+		},
+		rebuild: func(w *Writer, chainId, height uint64, item *badger.Item) error {
+			hash, err := readItem[primitives.Data32](w.db, item)
+			if err != nil {
+				return err
+			}
+			return insert(w, dbkey.BlockKeyByHash.Get(chainId, hash.Bytes()), &dbt.BlockKey{Height: height})
+		},
+	},
+	TxKeysIndex: {
+		name:            "txkeys",
+		derived:         dbkey.TxKeysByHash,
+		primary:         dbkey.TxHashes,
+		primaryForBlock: dbkey.TxHashesForBlock,
+		heights: func(txn *ViewTxn, chainId uint64) (*uint64, *uint64, error) {
+			earliest, err := txn.ReadEarliestTxKey(chainId)
+			if err != nil || earliest == nil {
+				return nil, nil, err
+			}
+			latest, err := txn.ReadLatestTxKey(chainId)
+			if err != nil || latest == nil {
+				return nil, nil, err
+			}
+			return &earliest.BlockHeight, &latest.BlockHeight, nil
+		},
+		rebuild: func(w *Writer, chainId, height uint64, item *badger.Item) error {
+			hash, err := readItem[primitives.Data32](w.db, item)
+			if err != nil {
+				return err
+			}
+			index := dbkey.TxHash.ReadUintVar(item.Key(), 2)
+			key := &dbt.TransactionKey{BlockHeight: height, TransactionIndex: index}
+			return insert(w, dbkey.TxKeyByHash.Get(chainId, hash.Bytes()), key)
+		},
+	},
+	LogScanIndex: {
+		name:            "logscan",
+		derived:         dbkey.LogScan,
+		primary:         dbkey.Logs,
+		primaryForBlock: dbkey.LogsForBlock,
+		heights: func(txn *ViewTxn, chainId uint64) (*uint64, *uint64, error) {
+			earliest, err := txn.ReadEarliestLogKey(chainId)
+			if err != nil || earliest == nil {
+				return nil, nil, err
+			}
+			latest, err := txn.ReadLatestLogKey(chainId)
+			if err != nil || latest == nil {
+				return nil, nil, err
+			}
+			return &earliest.BlockHeight, &latest.BlockHeight, nil
+		},
+		rebuild: func(w *Writer, chainId, height uint64, item *badger.Item) error {
+			data, err := readItem[dbt.Log](w.db, item)
+			if err != nil {
+				return err
+			}
+			txIndex := dbkey.Log.ReadUintVar(item.Key(), 2)
+			logIndex := dbkey.Log.ReadUintVar(item.Key(), 3)
+			for _, key := range logScanEntryKeys(chainId, height, txIndex, logIndex, data) {
+				if err := w.writer.Set(key, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+func (f IndexFamily) String() string {
+	if family, ok := indexFamilies[f]; ok {
+		return family.name
+	}
+	return fmt.Sprintf("IndexFamily(%d)", byte(f))
+}
+
+// IndexFamilyNames returns the names accepted by ParseIndexFamily
+func IndexFamilyNames() []string {
+	names := make([]string, 0, len(indexFamilies))
+	for _, family := range indexFamilies {
+		names = append(names, family.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func ParseIndexFamily(name string) (IndexFamily, error) {
+	for f, family := range indexFamilies {
+		if strings.EqualFold(family.name, name) {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown index family %q, expected one of %s", name, strings.Join(IndexFamilyNames(), ", "))
+}
+
+// ReindexProgress is reported to ReindexOptions.Progress each time the rebuilt range grows
+type ReindexProgress struct {
+	Family     IndexFamily
+	FromHeight uint64
+	ToHeight   uint64
+	// Height is the height up to which (exclusive) the index has been rebuilt
+	Height uint64
+	// Rows is the number of primary rows processed by this run
+	Rows uint64
+	// Resumed is set if the run continues an interrupted one
+	Resumed bool
+}
+
+type ReindexOptions struct {
+	// Workers is the number of height batches rebuilt in parallel, each one with its own WriteBatch, defaults to the
+	// number of CPUs
+	Workers int
+	// BatchHeights is the number of heights rebuilt by a single WriteBatch
+	BatchHeights uint64
+	Progress     func(ReindexProgress)
+}
+
+// Reindex drops the given secondary key family of the chain and rebuilds it from the primary rows.
+//
+// The height up to which the index has been rebuilt is stored under dbkey.ReindexState, so that an interrupted rebuild
+// is resumed from there by the next call instead of dropping the family again. The DB should not be written by the
+// indexer while the rebuild is running.
+func (db *DB) Reindex(chainId uint64, f IndexFamily, opts ReindexOptions) error {
+	family, ok := indexFamilies[f]
+	if !ok {
+		return fmt.Errorf("unknown index family %d", byte(f))
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.BatchHeights == 0 {
+		opts.BatchHeights = defaultReindexBatchHeights
+	}
+
+	stateKey := dbkey.ReindexState.Get(chainId, uint64(f))
+	var state *dbt.BlockKey
+	var from, to *uint64
+	err := db.View(func(txn *ViewTxn) error {
+		var err error
+		if state, err = read[dbt.BlockKey](txn, stateKey); err != nil {
+			return err
+		}
+		from, to, err = family.heights(txn, chainId)
+		return err
+	})
+	if err != nil {
+		db.logger.Errorf("DB: Can't start rebuilding %s index of chain %d: %v", family.name, chainId, err)
+		return err
+	}
+
+	progress := ReindexProgress{Family: f, Resumed: state != nil}
+	if state == nil {
+		if err := db.core.DropPrefix(family.derived.Get(chainId)); err != nil {
+			db.logger.Errorf("DB: Can't drop %s index of chain %d: %v", family.name, chainId, err)
+			return err
+		}
+		if from == nil {
+			return nil
+		}
+		state = &dbt.BlockKey{Height: *from}
+		if err := insertInstantly(db, stateKey, state); err != nil {
+			return err
+		}
+	}
+	if from != nil {
+		progress.FromHeight, progress.ToHeight, progress.Height = *from, *to, state.Height
+	}
+
+	var mu sync.Mutex
+	done := map[uint64]bool{}
+	// advance records a rebuilt batch and moves the stored state past all the batches rebuilt without gaps
+	advance := func(start, rows uint64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		done[start] = true
+		progress.Rows += rows
+		height := progress.Height
+		for done[height] {
+			delete(done, height)
+			height += opts.BatchHeights
+		}
+		if height == progress.Height {
+			return nil
+		}
+		if height > progress.ToHeight+1 {
+			height = progress.ToHeight + 1
+		}
+		if err := insertInstantly(db, stateKey, &dbt.BlockKey{Height: height}); err != nil {
+			return err
+		}
+		progress.Height = height
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+		return nil
+	}
+
+	eg, ctx := errgroup.WithContext(context.Background())
+	eg.SetLimit(opts.Workers)
+	for start := progress.Height; from != nil && start <= progress.ToHeight && ctx.Err() == nil; start += opts.BatchHeights {
+		start := start
+		end := start + opts.BatchHeights - 1
+		if end > progress.ToHeight {
+			end = progress.ToHeight
+		}
+		eg.Go(func() error {
+			rows, err := db.reindexBatch(chainId, family, start, end)
+			if err != nil {
+				return err
+			}
+			return advance(start, rows)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		db.logger.Errorf("DB: Can't rebuild %s index of chain %d: %v", family.name, chainId, err)
+		return err
+	}
+
+	err = db.core.Update(func(txn *badger.Txn) error {
+		return txn.Delete(stateKey)
+	})
+	if err != nil {
+		db.logger.Errorf("DB: Can't clear %s reindex state of chain %d: %v", family.name, chainId, err)
+		return err
+	}
+	return nil
+}
+
+// reindexBatch rebuilds the index entries of the primary rows within the given heights (inclusive) in a single
+// WriteBatch and returns the number of rows processed
+func (db *DB) reindexBatch(chainId uint64, family *indexFamily, from, to uint64) (uint64, error) {
+	w := db.NewWriter()
+	defer w.Cancel()
+
+	var rows uint64
+	err := db.View(func(txn *ViewTxn) error {
+		it := txn.txn.NewIterator(badger.IteratorOptions{
+			Prefix:         family.primary.Get(chainId),
+			PrefetchValues: true,
+		})
+		defer it.Close()
+		for it.Seek(family.primaryForBlock.Get(chainId, from)); it.Valid(); it.Next() {
+			height := family.primaryForBlock.ReadUintVar(it.Item().Key(), 1)
+			if height > to {
+				break
+			}
+			if err := family.rebuild(w, chainId, height, it.Item()); err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rows, w.Flush()
+}
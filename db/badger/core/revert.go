@@ -0,0 +1,177 @@
+package core
+
+import (
+	"github.com/aurora-is-near/relayer2-base/db/badger/core/dbkey"
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// keyDeleter is satisfied by both badger.Txn and badger.WriteBatch
+type keyDeleter interface {
+	Delete(key []byte) error
+}
+
+// RevertToHeight removes every block above the given height together with its transactions, logs and all their
+// indexes in a single transaction, so readers either see the whole range or none of it. Returns the logs of the
+// removed blocks flagged as removed.
+//
+// Since the whole range is deleted atomically, reverting too many blocks at once may fail with badger.ErrTxnTooBig.
+func (db *DB) RevertToHeight(chainId, height uint64) ([]*response.Log, error) {
+	var removed []*response.Log
+	err := db.Update(func(txn *ViewTxn) error {
+		latest, err := txn.readLatestHeight(chainId)
+		if err != nil || latest == nil {
+			return err
+		}
+		for h := height + 1; h <= *latest; h++ {
+			logs, err := txn.deleteBlock(txn.txn, chainId, h, true)
+			if err != nil {
+				return err
+			}
+			removed = append(removed, logs...)
+		}
+		return nil
+	})
+	if err != nil {
+		db.logger.Errorf("DB: Can't revert to height %d: %v", height, err)
+		return nil, err
+	}
+	return removed, nil
+}
+
+// readLatestHeight returns the highest height having any block, transaction or log record
+func (txn *ViewTxn) readLatestHeight(chainId uint64) (*uint64, error) {
+	var latest *uint64
+	blockKey, err := txn.ReadLatestBlockKey(chainId)
+	if err != nil {
+		return nil, err
+	}
+	if blockKey != nil {
+		latest = &blockKey.Height
+	}
+	txKey, err := txn.ReadLatestTxKey(chainId)
+	if err != nil {
+		return nil, err
+	}
+	if txKey != nil && (latest == nil || txKey.BlockHeight > *latest) {
+		latest = &txKey.BlockHeight
+	}
+	logKey, err := txn.ReadLatestLogKey(chainId)
+	if err != nil {
+		return nil, err
+	}
+	if logKey != nil && (latest == nil || logKey.BlockHeight > *latest) {
+		latest = &logKey.BlockHeight
+	}
+	return latest, nil
+}
+
+// deleteBlock deletes every key family of the block at the given height through d, the hash indexes are only
+// deleted if they still point to this height. Unless withPrimaries is set, only the hash indexes and the log scan
+// entries are deleted and the rows keyed by the height are left to the caller. Returns the logs of the block flagged as
+// removed.
+func (txn *ViewTxn) deleteBlock(d keyDeleter, chainId, height uint64, withPrimaries bool) ([]*response.Log, error) {
+	var keys [][]byte
+	if withPrimaries {
+		keys = append(keys, dbkey.BlockHash.Get(chainId, height), dbkey.BlockData.Get(chainId, height))
+	}
+
+	var blockHash primitives.Data32
+	hash, err := read[primitives.Data32](txn, dbkey.BlockHash.Get(chainId, height))
+	if err != nil {
+		return nil, err
+	}
+	if hash != nil {
+		blockHash = *hash
+		blockKey, err := txn.ReadBlockKey(chainId, blockHash)
+		if err != nil {
+			return nil, err
+		}
+		if blockKey != nil && blockKey.Height == height {
+			keys = append(keys, dbkey.BlockKeyByHash.Get(chainId, blockHash.Bytes()))
+		}
+	}
+
+	txHashes := make(map[uint64]primitives.Data32)
+	err = txn.iterateKeys(dbkey.TxHashesForBlock.Get(chainId, height), true, func(item *badger.Item) error {
+		txHash, err := readItem[primitives.Data32](txn.db, item)
+		if err != nil {
+			return err
+		}
+		txHashes[dbkey.TxHash.ReadUintVar(item.Key(), 2)] = *txHash
+		if withPrimaries {
+			keys = append(keys, item.KeyCopy(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for txIndex, txHash := range txHashes {
+		txKey, err := txn.ReadTxKey(chainId, txHash)
+		if err != nil {
+			return nil, err
+		}
+		if txKey != nil && txKey.BlockHeight == height && txKey.TransactionIndex == txIndex {
+			keys = append(keys, dbkey.TxKeyByHash.Get(chainId, txHash.Bytes()))
+		}
+	}
+
+	if withPrimaries {
+		err = txn.iterateKeys(dbkey.TxsDataForBlock.Get(chainId, height), false, func(item *badger.Item) error {
+			keys = append(keys, item.KeyCopy(nil))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var logs []*response.Log
+	err = txn.iterateKeys(dbkey.LogsForBlock.Get(chainId, height), true, func(item *badger.Item) error {
+		data, err := readItem[dbt.Log](txn.db, item)
+		if err != nil {
+			return err
+		}
+		txIndex := dbkey.Log.ReadUintVar(item.Key(), 2)
+		logIndex := dbkey.Log.ReadUintVar(item.Key(), 3)
+		if withPrimaries {
+			keys = append(keys, item.KeyCopy(nil))
+		}
+		keys = append(keys, logScanEntryKeys(chainId, height, txIndex, logIndex, data)...)
+
+		log := makeLogResponse(height, txIndex, logIndex, blockHash, txHashes[txIndex], data)
+		log.Removed = true
+		logs = append(logs, log)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if err := d.Delete(key); err != nil {
+			return nil, err
+		}
+	}
+	return logs, nil
+}
+
+// iterateKeys calls fn for every item under the given prefix, the iterator is closed before returning so that
+// the keys can be deleted afterwards in the same read-write transaction
+func (txn *ViewTxn) iterateKeys(prefix []byte, withValues bool, fn func(item *badger.Item) error) error {
+	it := txn.txn.NewIterator(badger.IteratorOptions{
+		Prefix:         prefix,
+		PrefetchValues: withValues,
+	})
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		if err := fn(it.Item()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
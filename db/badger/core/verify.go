@@ -0,0 +1,242 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/aurora-is-near/relayer2-base/db/badger/core/dbkey"
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// maxVerifySamples limits the number of examples kept per issue in the verification report
+const maxVerifySamples = 20
+
+// VerifyIssue counts the occurrences of one kind of inconsistency and keeps a few of them as examples
+type VerifyIssue struct {
+	Count    uint64   `json:"count"`
+	Repaired uint64   `json:"repaired,omitempty"`
+	Samples  []string `json:"samples,omitempty"`
+}
+
+func (i *VerifyIssue) add(format string, args ...any) {
+	i.addN(1, format, args...)
+}
+
+func (i *VerifyIssue) addN(n uint64, format string, args ...any) {
+	i.Count += n
+	if len(i.Samples) < maxVerifySamples {
+		i.Samples = append(i.Samples, fmt.Sprintf(format, args...))
+	}
+}
+
+// VerifyReport is the result of DB.Verify
+type VerifyReport struct {
+	ChainId        uint64  `json:"chainId"`
+	EarliestHeight *uint64 `json:"earliestHeight"`
+	LatestHeight   *uint64 `json:"latestHeight"`
+	Blocks         uint64  `json:"blocks"`
+	Transactions   uint64  `json:"transactions"`
+	Logs           uint64  `json:"logs"`
+
+	HeightGaps            VerifyIssue `json:"heightGaps"`
+	MissingBlockData      VerifyIssue `json:"missingBlockData"`
+	MissingBlockKeys      VerifyIssue `json:"missingBlockKeyByHash"`
+	MismatchedBlockKeys   VerifyIssue `json:"mismatchedBlockKeyByHash"`
+	MissingTxData         VerifyIssue `json:"missingTxData"`
+	MissingTxKeys         VerifyIssue `json:"missingTxKeyByHash"`
+	MismatchedTxKeys      VerifyIssue `json:"mismatchedTxKeyByHash"`
+	MissingLogScanEntries VerifyIssue `json:"missingLogScanEntries"`
+}
+
+func (r *VerifyReport) issues() []*VerifyIssue {
+	return []*VerifyIssue{
+		&r.HeightGaps,
+		&r.MissingBlockData,
+		&r.MissingBlockKeys,
+		&r.MismatchedBlockKeys,
+		&r.MissingTxData,
+		&r.MissingTxKeys,
+		&r.MismatchedTxKeys,
+		&r.MissingLogScanEntries,
+	}
+}
+
+// Consistent returns true if every issue found has been repaired
+func (r *VerifyReport) Consistent() bool {
+	for _, issue := range r.issues() {
+		if issue.Count != issue.Repaired {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify walks the blocks, transactions and logs of the given chain and checks that their secondary indexes
+// (BlockKeyByHash, TxKeyByHash and LogScanEntry) are in place and that there are no gaps between block heights.
+// If repair is set, missing secondary indexes are written back. Indexes pointing to another record are only
+// reported since it can't be told which one is right.
+func (db *DB) Verify(chainId uint64, repair bool) (*VerifyReport, error) {
+	report := &VerifyReport{ChainId: chainId}
+	var w *Writer
+	if repair {
+		w = db.NewWriter()
+		defer w.Cancel()
+	}
+
+	err := db.View(func(txn *ViewTxn) error {
+		if err := txn.verifyBlocks(report, w); err != nil {
+			return err
+		}
+		if err := txn.verifyTxs(report, w); err != nil {
+			return err
+		}
+		return txn.verifyLogs(report, w)
+	})
+	if err != nil {
+		db.logger.Errorf("DB: Can't verify chain %d: %v", chainId, err)
+		return nil, err
+	}
+	if repair {
+		if err := w.Flush(); err != nil {
+			db.logger.Errorf("DB: Can't write repaired indexes: %v", err)
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+func (txn *ViewTxn) verifyBlocks(report *VerifyReport, w *Writer) error {
+	chainId := report.ChainId
+	return txn.iterateKeys(dbkey.BlockHashes.Get(chainId), true, func(item *badger.Item) error {
+		height := dbkey.BlockHash.ReadUintVar(item.Key(), 1)
+		hash, err := readItem[primitives.Data32](txn.db, item)
+		if err != nil {
+			return err
+		}
+
+		report.Blocks++
+		if report.EarliestHeight == nil {
+			report.EarliestHeight = &height
+		} else if height > *report.LatestHeight+1 {
+			report.HeightGaps.add("heights %d-%d", *report.LatestHeight+1, height-1)
+		}
+		latest := height
+		report.LatestHeight = &latest
+
+		ok, err := txn.exists(dbkey.BlockData.Get(chainId, height))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			report.MissingBlockData.add("height %d", height)
+		}
+
+		key := dbkey.BlockKeyByHash.Get(chainId, hash.Bytes())
+		blockKey, err := read[dbt.BlockKey](txn, key)
+		if err != nil {
+			return err
+		}
+		if blockKey == nil {
+			report.MissingBlockKeys.add("height %d, hash %s", height, hash.Hex())
+			if w != nil {
+				if err := insert(w, key, &dbt.BlockKey{Height: height}); err != nil {
+					return err
+				}
+				report.MissingBlockKeys.Repaired++
+			}
+		} else if blockKey.Height != height {
+			report.MismatchedBlockKeys.add("height %d, hash %s points to height %d", height, hash.Hex(), blockKey.Height)
+		}
+		return nil
+	})
+}
+
+func (txn *ViewTxn) verifyTxs(report *VerifyReport, w *Writer) error {
+	chainId := report.ChainId
+	return txn.iterateKeys(dbkey.TxHashes.Get(chainId), true, func(item *badger.Item) error {
+		height := dbkey.TxHash.ReadUintVar(item.Key(), 1)
+		index := dbkey.TxHash.ReadUintVar(item.Key(), 2)
+		hash, err := readItem[primitives.Data32](txn.db, item)
+		if err != nil {
+			return err
+		}
+
+		report.Transactions++
+		ok, err := txn.exists(dbkey.TxData.Get(chainId, height, index))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			report.MissingTxData.add("height %d, index %d", height, index)
+		}
+
+		key := dbkey.TxKeyByHash.Get(chainId, hash.Bytes())
+		txKey, err := read[dbt.TransactionKey](txn, key)
+		if err != nil {
+			return err
+		}
+		if txKey == nil {
+			report.MissingTxKeys.add("height %d, index %d, hash %s", height, index, hash.Hex())
+			if w != nil {
+				if err := insert(w, key, &dbt.TransactionKey{BlockHeight: height, TransactionIndex: index}); err != nil {
+					return err
+				}
+				report.MissingTxKeys.Repaired++
+			}
+		} else if txKey.BlockHeight != height || txKey.TransactionIndex != index {
+			report.MismatchedTxKeys.add("height %d, index %d, hash %s points to height %d, index %d",
+				height, index, hash.Hex(), txKey.BlockHeight, txKey.TransactionIndex)
+		}
+		return nil
+	})
+}
+
+func (txn *ViewTxn) verifyLogs(report *VerifyReport, w *Writer) error {
+	chainId := report.ChainId
+	return txn.iterateKeys(dbkey.Logs.Get(chainId), true, func(item *badger.Item) error {
+		height := dbkey.Log.ReadUintVar(item.Key(), 1)
+		txIndex := dbkey.Log.ReadUintVar(item.Key(), 2)
+		logIndex := dbkey.Log.ReadUintVar(item.Key(), 3)
+		data, err := readItem[dbt.Log](txn.db, item)
+		if err != nil {
+			return err
+		}
+
+		report.Logs++
+		missing := uint64(0)
+		for _, key := range logScanEntryKeys(chainId, height, txIndex, logIndex, data) {
+			ok, err := txn.exists(key)
+			if err != nil {
+				return err
+			}
+			if ok {
+				continue
+			}
+			missing++
+			if w != nil {
+				if err := w.writer.Set(key, nil); err != nil {
+					return err
+				}
+				report.MissingLogScanEntries.Repaired++
+			}
+		}
+		if missing > 0 {
+			report.MissingLogScanEntries.addN(missing, "height %d, tx %d, log %d misses %d rows", height, txIndex, logIndex, missing)
+		}
+		return nil
+	})
+}
+
+func (txn *ViewTxn) exists(key []byte) (bool, error) {
+	_, err := txn.txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		txn.db.logger.Errorf("DB: Can't fetch item for key %v: %v", key, err)
+		return false, err
+	}
+	return true, nil
+}
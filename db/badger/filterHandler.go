@@ -26,6 +26,7 @@ func NewFilterHandlerWithCodec(codec codec.Codec) (*FilterHandler, error) {
 	if err != nil {
 		return nil, err
 	}
+	db.StartFilterGC()
 	return &FilterHandler{
 		db:     db,
 		Config: config,
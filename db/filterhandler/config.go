@@ -0,0 +1,44 @@
+package filterhandler
+
+import (
+	"github.com/aurora-is-near/relayer2-base/cmdutils"
+	"github.com/aurora-is-near/relayer2-base/log"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// BadgerFilterHandler persists the filters in the badger DB, see badger.FilterHandler
+	BadgerFilterHandler = "badger"
+	// MemoryFilterHandler keeps the filters in memory, see memory.FilterHandler
+	MemoryFilterHandler = "memory"
+	// RedisFilterHandler shares the filters between relayers through a Redis protocol server, see redis.FilterHandler
+	RedisFilterHandler = "redis"
+
+	defaultFilterHandler = BadgerFilterHandler
+
+	configPath = "db"
+)
+
+type Config struct {
+	FilterHandler string `mapstructure:"filterHandler"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		FilterHandler: defaultFilterHandler,
+	}
+}
+
+func GetConfig() *Config {
+	config := defaultConfig()
+	sub := viper.Sub(configPath)
+	if sub != nil {
+		cmdutils.BindSubViper(sub, configPath)
+		if err := sub.Unmarshal(&config); err != nil {
+			log.Log().Warn().Err(err).Msgf("failed to parse configuration [%s] from [%s], "+
+				"falling back to defaults", configPath, viper.ConfigFileUsed())
+		}
+	}
+	return config
+}
@@ -0,0 +1,31 @@
+package filterhandler
+
+import (
+	"fmt"
+
+	"github.com/aurora-is-near/relayer2-base/db"
+	"github.com/aurora-is-near/relayer2-base/db/badger"
+	"github.com/aurora-is-near/relayer2-base/db/memory"
+	"github.com/aurora-is-near/relayer2-base/db/redis"
+)
+
+// New creates the db.FilterHandler selected by the "db.filterHandler" setting
+func New() (db.FilterHandler, error) {
+	var fh db.FilterHandler
+	var err error
+	switch name := GetConfig().FilterHandler; name {
+	case BadgerFilterHandler:
+		fh, err = badger.NewFilterHandler()
+	case MemoryFilterHandler:
+		fh, err = memory.NewFilterHandler()
+	case RedisFilterHandler:
+		fh, err = redis.NewFilterHandler()
+	default:
+		err = fmt.Errorf("unknown filter handler %q, expected one of %s, %s, %s", name, BadgerFilterHandler,
+			MemoryFilterHandler, RedisFilterHandler)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fh, nil
+}
@@ -2,7 +2,7 @@ package db
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"github.com/aurora-is-near/relayer2-base/types/common"
 	"github.com/aurora-is-near/relayer2-base/types/db"
 	"github.com/aurora-is-near/relayer2-base/types/indexer"
@@ -27,8 +27,10 @@ type BlockHandler interface {
 	GetTransactionByBlockHashAndIndex(ctx context.Context, hash common.H256, index common.Uint64) (*response.Transaction, error)
 	GetTransactionByBlockNumberAndIndex(ctx context.Context, number common.BN64, index common.Uint64) (*response.Transaction, error)
 	GetTransactionReceipt(ctx context.Context, hash common.H256) (*response.TransactionReceipt, error)
+	GetBlockReceipts(ctx context.Context, blockNumberOrHash common.BlockNumberOrHash) ([]*response.TransactionReceipt, error)
 
 	GetLogs(ctx context.Context, filter *db.LogFilter) ([]*response.Log, error)
+	GetLogsPage(ctx context.Context, filter *db.LogFilter) ([]*response.Log, *db.LogKey, error)
 	GetFilterLogs(ctx context.Context, filter *db.LogFilter) ([]*response.Log, error)
 	GetFilterChanges(ctx context.Context, filter any) (*[]interface{}, error)
 
@@ -36,6 +38,7 @@ type BlockHandler interface {
 	BlockNumberToHash(ctx context.Context, number common.BN64) (*string, error)
 
 	InsertBlock(block *indexer.Block) error
+	RevertToHeight(ctx context.Context, height uint64) ([]*response.Log, error)
 
 	SetIndexerState(chainId uint64, data []byte) error
 	GetIndexerState(chainId uint64) ([]byte, error)
@@ -73,5 +76,8 @@ func (h StoreHandler) Close() error {
 	if err != nil {
 		errs = append(errs, err.Error())
 	}
-	return fmt.Errorf(strings.Join(errs, "\n"))
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, "\n"))
 }
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"github.com/aurora-is-near/relayer2-base/cmdutils"
+	"github.com/aurora-is-near/relayer2-base/log"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultFilterTtlMinutes        = 15
+	defaultFilterGcIntervalSeconds = 60
+	defaultMaxFilters              = 0
+
+	configPath = "db.memory"
+)
+
+type Config struct {
+	FilterTtlMinutes        int `mapstructure:"filterTtlMinutes"`
+	FilterGcIntervalSeconds int `mapstructure:"filterGcIntervalSeconds"`
+	// MaxFilters caps the number of filters kept by the handler, no cap if not positive
+	MaxFilters int `mapstructure:"maxFilters"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		FilterTtlMinutes:        defaultFilterTtlMinutes,
+		FilterGcIntervalSeconds: defaultFilterGcIntervalSeconds,
+		MaxFilters:              defaultMaxFilters,
+	}
+}
+
+func GetConfig() *Config {
+	config := defaultConfig()
+	sub := viper.Sub(configPath)
+	if sub != nil {
+		cmdutils.BindSubViper(sub, configPath)
+		if err := sub.Unmarshal(&config); err != nil {
+			log.Log().Warn().Err(err).Msgf("failed to parse configuration [%s] from [%s], "+
+				"falling back to defaults", configPath, viper.ConfigFileUsed())
+		}
+	}
+	return config
+}
@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/log"
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/utils"
+
+	"github.com/puzpuzpuz/xsync/v2"
+)
+
+// FilterHandler keeps the filters in a concurrent in-memory map instead of the DB. The filters don't survive restarts,
+// they expire after not being polled for the configured TTL and are swept by a background goroutine.
+type FilterHandler struct {
+	Config *Config
+
+	filters *xsync.MapOf[string, any]
+	ttl     time.Duration
+	// insertMtx serializes the insertion of new filters so that the size cap is not exceeded
+	insertMtx sync.Mutex
+	closeOnce sync.Once
+	gcStop    chan bool
+}
+
+type expirable interface {
+	Expired(now time.Time, ttl time.Duration) bool
+}
+
+func NewFilterHandler() (*FilterHandler, error) {
+	return NewFilterHandlerWithConfig(GetConfig()), nil
+}
+
+func NewFilterHandlerWithConfig(config *Config) *FilterHandler {
+	h := &FilterHandler{
+		Config:  config,
+		filters: xsync.NewMapOf[any](),
+		ttl:     time.Duration(config.FilterTtlMinutes) * time.Minute,
+	}
+	if h.ttl > 0 && config.FilterGcIntervalSeconds > 0 {
+		h.gcStop = make(chan bool)
+		go h.runGC(time.Duration(config.FilterGcIntervalSeconds)*time.Second, h.gcStop)
+	}
+	return h
+}
+
+func (h *FilterHandler) GetFilter(ctx context.Context, filterId primitives.Data32) (any, error) {
+	filter, ok := h.filters.Load(filterKey(ctx, filterId))
+	if !ok || filter.(expirable).Expired(time.Now(), h.ttl) {
+		return nil, errors.New("filter not found")
+	}
+	switch f := filter.(type) {
+	case *dbt.BlockFilter:
+		return clone(f), nil
+	case *dbt.TransactionFilter:
+		return clone(f), nil
+	case *dbt.LogFilter:
+		return clone(f), nil
+	}
+	return nil, errors.New("unknown filter type")
+}
+
+func (h *FilterHandler) GetBlockFilter(ctx context.Context, filterId primitives.Data32) (*dbt.BlockFilter, error) {
+	return getFilter[dbt.BlockFilter](h, ctx, filterId)
+}
+
+func (h *FilterHandler) GetTransactionFilter(ctx context.Context, filterId primitives.Data32) (*dbt.TransactionFilter, error) {
+	return getFilter[dbt.TransactionFilter](h, ctx, filterId)
+}
+
+func (h *FilterHandler) GetLogFilter(ctx context.Context, filterId primitives.Data32) (*dbt.LogFilter, error) {
+	return getFilter[dbt.LogFilter](h, ctx, filterId)
+}
+
+func (h *FilterHandler) StoreFilter(ctx context.Context, filterId primitives.Data32, filter any) error {
+	if bf, ok := filter.(*dbt.BlockFilter); ok {
+		return h.StoreBlockFilter(ctx, filterId, bf)
+	} else if tf, ok := filter.(*dbt.TransactionFilter); ok {
+		return h.StoreTransactionFilter(ctx, filterId, tf)
+	} else if lf, ok := filter.(*dbt.LogFilter); ok {
+		return h.StoreLogFilter(ctx, filterId, lf)
+	}
+	return errors.New("unknown filter type")
+}
+
+func (h *FilterHandler) StoreBlockFilter(ctx context.Context, filterId primitives.Data32, filter *dbt.BlockFilter) error {
+	return h.store(filterKey(ctx, filterId), clone(filter))
+}
+
+func (h *FilterHandler) StoreTransactionFilter(ctx context.Context, filterId primitives.Data32, filter *dbt.TransactionFilter) error {
+	return h.store(filterKey(ctx, filterId), clone(filter))
+}
+
+func (h *FilterHandler) StoreLogFilter(ctx context.Context, filterId primitives.Data32, filter *dbt.LogFilter) error {
+	return h.store(filterKey(ctx, filterId), clone(filter))
+}
+
+func (h *FilterHandler) DeleteFilter(ctx context.Context, filterId primitives.Data32) error {
+	filter, ok := h.filters.LoadAndDelete(filterKey(ctx, filterId))
+	if !ok || filter.(expirable).Expired(time.Now(), h.ttl) {
+		return errors.New("filter not found")
+	}
+	return nil
+}
+
+// DeleteExpiredFilters deletes the filters which have not been polled for longer than the filter TTL, returns the
+// number of deleted filters
+func (h *FilterHandler) DeleteExpiredFilters(now time.Time) int {
+	if h.ttl <= 0 {
+		return 0
+	}
+	n := 0
+	h.filters.Range(func(key string, filter any) bool {
+		if filter.(expirable).Expired(now, h.ttl) {
+			h.filters.Delete(key)
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// Size returns the number of filters kept by the handler, including the expired ones which are not swept yet
+func (h *FilterHandler) Size() int {
+	return h.filters.Size()
+}
+
+func (h *FilterHandler) Close() error {
+	h.closeOnce.Do(func() {
+		if h.gcStop != nil {
+			close(h.gcStop)
+		}
+		h.filters.Clear()
+	})
+	return nil
+}
+
+// store replaces the filter stored under key, a new filter is rejected if the size cap is reached even after sweeping
+// the expired filters
+func (h *FilterHandler) store(key string, filter any) error {
+	if h.Config.MaxFilters > 0 {
+		if _, ok := h.filters.Load(key); !ok {
+			h.insertMtx.Lock()
+			defer h.insertMtx.Unlock()
+			if h.filters.Size() >= h.Config.MaxFilters {
+				h.DeleteExpiredFilters(time.Now())
+			}
+			if h.filters.Size() >= h.Config.MaxFilters {
+				return errors.New("too many filters")
+			}
+		}
+	}
+	h.filters.Store(key, filter)
+	return nil
+}
+
+func (h *FilterHandler) runGC(interval time.Duration, stop chan bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if n := h.DeleteExpiredFilters(time.Now()); n > 0 {
+				log.Log().Debug().Msgf("deleted %d expired filters", n)
+			}
+		}
+	}
+}
+
+// getFilter returns a copy of the filter of type T, so that the changes made by the caller are only seen by the other
+// callers once the filter is stored back
+func getFilter[T any](h *FilterHandler, ctx context.Context, filterId primitives.Data32) (*T, error) {
+	filter, ok := h.filters.Load(filterKey(ctx, filterId))
+	if ok {
+		if f, ok := filter.(*T); ok && !filter.(expirable).Expired(time.Now(), h.ttl) {
+			return clone(f), nil
+		}
+	}
+	return nil, errors.New("filter not found")
+}
+
+func filterKey(ctx context.Context, filterId primitives.Data32) string {
+	key := make([]byte, 8, 8+len(filterId.Bytes()))
+	binary.BigEndian.PutUint64(key, utils.GetChainId(ctx))
+	return string(append(key, filterId.Bytes()...))
+}
+
+func clone[T any](filter *T) *T {
+	c := *filter
+	return &c
+}
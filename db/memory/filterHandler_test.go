@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterHandler(t *testing.T) {
+	h := NewFilterHandlerWithConfig(&Config{FilterTtlMinutes: 15, MaxFilters: 2})
+	defer h.Close()
+
+	var chainId uint64 = 1313161554
+	ctx := utils.PutChainId(context.Background(), chainId)
+	now := uint64(time.Now().UnixNano())
+	blockId := primitives.MustData32FromHex("0x1")
+	logId := primitives.MustData32FromHex("0x2")
+
+	require.NoError(t, h.StoreBlockFilter(ctx, blockId, &dbt.BlockFilter{From: dbt.BlockKey{Height: 5}, LastPolledAt: now}))
+	require.NoError(t, h.StoreFilter(ctx, logId, &dbt.LogFilter{LastPolledAt: now}))
+
+	bf, err := h.GetBlockFilter(ctx, blockId)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), bf.From.Height)
+	// changes are not visible until the filter is stored back
+	bf.Next = dbt.BlockKey{Height: 7}
+	f, err := h.GetFilter(ctx, blockId)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), f.(*dbt.BlockFilter).Next.Height)
+	require.NoError(t, h.StoreFilter(ctx, blockId, bf))
+	f, err = h.GetFilter(ctx, blockId)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), f.(*dbt.BlockFilter).Next.Height)
+
+	_, err = h.GetTransactionFilter(ctx, blockId)
+	assert.Error(t, err, "filter of another type")
+	_, err = h.GetLogFilter(utils.PutChainId(context.Background(), 1), logId)
+	assert.Error(t, err, "filter of another chain")
+
+	txId := primitives.MustData32FromHex("0x3")
+	assert.Error(t, h.StoreTransactionFilter(ctx, txId, &dbt.TransactionFilter{LastPolledAt: now}), "size cap")
+	assert.NoError(t, h.StoreBlockFilter(ctx, blockId, bf), "updates are not capped")
+
+	expired := uint64(time.Now().Add(-time.Hour).UnixNano())
+	require.NoError(t, h.StoreLogFilter(ctx, logId, &dbt.LogFilter{LastPolledAt: expired}))
+	_, err = h.GetLogFilter(ctx, logId)
+	assert.Error(t, err, "expired filter")
+	assert.NoError(t, h.StoreTransactionFilter(ctx, txId, &dbt.TransactionFilter{LastPolledAt: now}),
+		"expired filters are swept when the cap is reached")
+	assert.Equal(t, 2, h.Size())
+
+	require.NoError(t, h.DeleteFilter(ctx, txId))
+	assert.Error(t, h.DeleteFilter(ctx, txId))
+	_, err = h.GetFilter(ctx, txId)
+	assert.Error(t, err)
+
+	require.NoError(t, h.StoreLogFilter(ctx, logId, &dbt.LogFilter{LastPolledAt: expired}))
+	assert.Equal(t, 1, h.DeleteExpiredFilters(time.Now()))
+	assert.Equal(t, 1, h.Size())
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+
+	"github.com/aurora-is-near/relayer2-base/broker"
+	"github.com/aurora-is-near/relayer2-base/log"
+	"github.com/aurora-is-near/relayer2-base/types/common"
+	"github.com/aurora-is-near/relayer2-base/types/db"
+	"github.com/aurora-is-near/relayer2-base/types/event"
+	"github.com/aurora-is-near/relayer2-base/types/indexer"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+	"github.com/aurora-is-near/relayer2-base/utils"
+)
+
+// PublishingBlockHandler decorates a BlockHandler so that the changes made through it are published to the
+// subscribers of the given broker.
+type PublishingBlockHandler struct {
+	BlockHandler
+	Broker broker.Broker
+	Logger *log.Logger
+}
+
+func NewPublishingBlockHandler(bh BlockHandler, b broker.Broker) *PublishingBlockHandler {
+	return &PublishingBlockHandler{
+		BlockHandler: bh,
+		Broker:       b,
+		Logger:       log.Log(),
+	}
+}
+
+// InsertBlock inserts the block and, once it is written, publishes its header and logs as they are read back through
+// the decorated handler, so the subscribers get the same payloads as eth_getBlockByNumber and eth_getLogs and never see
+// a block that can not be queried yet. A failure to read the block or its logs back is logged and nothing is published,
+// it does not fail the insertion.
+func (h *PublishingBlockHandler) InsertBlock(block *indexer.Block) error {
+	if err := h.BlockHandler.InsertBlock(block); err != nil {
+		return err
+	}
+
+	ctx := utils.PutChainId(context.Background(), block.ChainId)
+	head, err := h.BlockHandler.GetBlockByNumber(ctx, common.BN64(block.Height), false)
+	if err != nil || head == nil {
+		h.Logger.Error().Err(err).Msgf("failed to read back block [%d] to publish", block.Height)
+		return nil
+	}
+	logs, err := h.blockLogs(ctx, block.Height)
+	if err != nil {
+		h.Logger.Error().Err(err).Msgf("failed to read back logs of block [%d] to publish", block.Height)
+		return nil
+	}
+
+	h.Broker.PublishNewHeads(event.Block(head))
+	if len(logs) > 0 {
+		h.Broker.PublishLogs(event.Logs(logs))
+	}
+	return nil
+}
+
+// blockLogs reads all the logs of the block page by page, so that they are not subject to the response limits of
+// eth_getLogs
+func (h *PublishingBlockHandler) blockLogs(ctx context.Context, height uint64) ([]*response.Log, error) {
+	from, to := db.BlockLogKeys(height)
+	filter := &db.LogFilter{From: from, To: to}
+	var logs []*response.Log
+	for {
+		page, next, err := h.BlockHandler.GetLogsPage(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, page...)
+		if next == nil {
+			return logs, nil
+		}
+		filter.From = *next
+	}
+}
+
+// RevertToHeight reverts the underlying store and publishes the logs of the removed blocks, flagged with
+// `removed: true`, so that the log subscribers see the rollback.
+func (h *PublishingBlockHandler) RevertToHeight(ctx context.Context, height uint64) ([]*response.Log, error) {
+	logs, err := h.BlockHandler.RevertToHeight(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) > 0 {
+		h.Broker.PublishLogs(event.Logs(logs))
+	}
+	return logs, nil
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aurora-is-near/relayer2-base/broker"
+	"github.com/aurora-is-near/relayer2-base/types/common"
+	"github.com/aurora-is-near/relayer2-base/types/db"
+	"github.com/aurora-is-near/relayer2-base/types/event"
+	"github.com/aurora-is-near/relayer2-base/types/indexer"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+	"github.com/aurora-is-near/relayer2-base/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubBlockHandler struct {
+	BlockHandler
+	inserted  []uint64
+	insertErr error
+	logsErr   error
+}
+
+func (h *stubBlockHandler) InsertBlock(block *indexer.Block) error {
+	if h.insertErr != nil {
+		return h.insertErr
+	}
+	h.inserted = append(h.inserted, block.Height)
+	return nil
+}
+
+func (h *stubBlockHandler) GetBlockByNumber(ctx context.Context, number common.BN64, _ bool) (*response.Block, error) {
+	if utils.GetChainId(ctx) != 1 || len(h.inserted) == 0 || h.inserted[len(h.inserted)-1] != uint64(number) {
+		return nil, nil
+	}
+	return &response.Block{Number: primitives.HexUint(number)}, nil
+}
+
+// GetLogsPage returns a single log per page, two logs per block
+func (h *stubBlockHandler) GetLogsPage(_ context.Context, filter *db.LogFilter) ([]*response.Log, *db.LogKey, error) {
+	if filter.From.BlockHeight != filter.To.BlockHeight {
+		return nil, nil, errors.New("unexpected range")
+	}
+	if filter.From.LogIndex > 0 && h.logsErr != nil {
+		return nil, nil, h.logsErr
+	}
+	logs := []*response.Log{{
+		BlockNumber: primitives.HexUint(filter.From.BlockHeight),
+		LogIndex:    primitives.HexUint(filter.From.LogIndex),
+	}}
+	if filter.From.LogIndex > 0 {
+		return logs, nil, nil
+	}
+	return logs, &db.LogKey{BlockHeight: filter.From.BlockHeight, LogIndex: 1}, nil
+}
+
+type recordingBroker struct {
+	broker.Broker
+	heads []event.Block
+	logs  []event.Logs
+}
+
+func (b *recordingBroker) PublishNewHeads(h event.Block) { b.heads = append(b.heads, h) }
+
+func (b *recordingBroker) PublishLogs(l event.Logs) { b.logs = append(b.logs, l) }
+
+func TestPublishingBlockHandlerInsertBlock(t *testing.T) {
+	bh := &stubBlockHandler{}
+	b := &recordingBroker{}
+	h := NewPublishingBlockHandler(bh, b)
+
+	require.NoError(t, h.InsertBlock(&indexer.Block{ChainId: 1, Height: 7}))
+	require.Len(t, b.heads, 1)
+	assert.Equal(t, primitives.HexUint(7), b.heads[0].Number)
+	require.Len(t, b.logs, 1)
+	require.Len(t, b.logs[0], 2, "all the pages of logs must be published")
+	assert.Equal(t, primitives.HexUint(7), b.logs[0][0].BlockNumber)
+	assert.Equal(t, primitives.HexUint(1), b.logs[0][1].LogIndex)
+
+	bh.logsErr = errors.New("read failed")
+	require.NoError(t, h.InsertBlock(&indexer.Block{ChainId: 1, Height: 8}))
+	assert.Len(t, b.heads, 1, "blocks whose logs can not be read must not be published")
+	assert.Len(t, b.logs, 1)
+	bh.logsErr = nil
+
+	bh.insertErr = errors.New("insert failed")
+	require.Error(t, h.InsertBlock(&indexer.Block{ChainId: 1, Height: 9}))
+	assert.Len(t, b.heads, 1, "failed insertions must not be published")
+	assert.Len(t, b.logs, 1)
+}
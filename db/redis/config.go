@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"github.com/aurora-is-near/relayer2-base/cmdutils"
+	"github.com/aurora-is-near/relayer2-base/log"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultAddr             = "localhost:6379"
+	defaultKeyPrefix        = "relayer"
+	defaultFilterTtlMinutes = 15
+
+	configPath = "db.redis"
+)
+
+type Config struct {
+	Addr     string `mapstructure:"addr"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	Db       int    `mapstructure:"db"`
+	// KeyPrefix namespaces the keys, so that several relayer deployments can share the same server
+	KeyPrefix        string `mapstructure:"keyPrefix"`
+	FilterTtlMinutes int    `mapstructure:"filterTtlMinutes"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Addr:             defaultAddr,
+		KeyPrefix:        defaultKeyPrefix,
+		FilterTtlMinutes: defaultFilterTtlMinutes,
+	}
+}
+
+func GetConfig() *Config {
+	config := defaultConfig()
+	sub := viper.Sub(configPath)
+	if sub != nil {
+		cmdutils.BindSubViper(sub, configPath)
+		if err := sub.Unmarshal(&config); err != nil {
+			log.Log().Warn().Err(err).Msgf("failed to parse configuration [%s] from [%s], "+
+				"falling back to defaults", configPath, viper.ConfigFileUsed())
+		}
+	}
+	return config
+}
@@ -0,0 +1,176 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/db/codec"
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/utils"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// filter values are prefixed with their type, so that GetFilter knows what to decode
+const (
+	blockFilterType byte = iota + 1
+	transactionFilterType
+	logFilterType
+)
+
+var errFilterNotFound = errors.New("filter not found")
+
+// FilterHandler stores the filters in a server speaking the Redis protocol, so that they are shared by all the relayers
+// connected to it. The filters are encoded with the codec and expire natively after not being stored for the
+// configured TTL, which is refreshed on every eth_getFilterChanges as the updated filter is stored back.
+type FilterHandler struct {
+	Config *Config
+	client *goredis.Client
+	codec  codec.Codec
+	ttl    time.Duration
+}
+
+func NewFilterHandler() (*FilterHandler, error) {
+	return NewFilterHandlerWithCodec(codec.NewTinypackCodec())
+}
+
+func NewFilterHandlerWithCodec(codec codec.Codec) (*FilterHandler, error) {
+	return NewFilterHandlerWithConfig(GetConfig(), codec)
+}
+
+func NewFilterHandlerWithConfig(config *Config, codec codec.Codec) (*FilterHandler, error) {
+	client := goredis.NewClient(&goredis.Options{
+		Addr:     config.Addr,
+		Username: config.Username,
+		Password: config.Password,
+		DB:       config.Db,
+	})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to filter store at %s: %w", config.Addr, err)
+	}
+	return &FilterHandler{
+		Config: config,
+		client: client,
+		codec:  codec,
+		ttl:    time.Duration(config.FilterTtlMinutes) * time.Minute,
+	}, nil
+}
+
+func (h *FilterHandler) GetFilter(ctx context.Context, filterId primitives.Data32) (any, error) {
+	data, err := h.client.Get(ctx, h.filterKey(ctx, filterId)).Bytes()
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("unknown filter type")
+	}
+	switch data[0] {
+	case blockFilterType:
+		return decode[dbt.BlockFilter](h, data[1:])
+	case transactionFilterType:
+		return decode[dbt.TransactionFilter](h, data[1:])
+	case logFilterType:
+		return decode[dbt.LogFilter](h, data[1:])
+	}
+	return nil, errors.New("unknown filter type")
+}
+
+func (h *FilterHandler) GetBlockFilter(ctx context.Context, filterId primitives.Data32) (*dbt.BlockFilter, error) {
+	return getFilter[dbt.BlockFilter](h, ctx, filterId, blockFilterType)
+}
+
+func (h *FilterHandler) GetTransactionFilter(ctx context.Context, filterId primitives.Data32) (*dbt.TransactionFilter, error) {
+	return getFilter[dbt.TransactionFilter](h, ctx, filterId, transactionFilterType)
+}
+
+func (h *FilterHandler) GetLogFilter(ctx context.Context, filterId primitives.Data32) (*dbt.LogFilter, error) {
+	return getFilter[dbt.LogFilter](h, ctx, filterId, logFilterType)
+}
+
+func (h *FilterHandler) StoreFilter(ctx context.Context, filterId primitives.Data32, filter any) error {
+	if bf, ok := filter.(*dbt.BlockFilter); ok {
+		return h.StoreBlockFilter(ctx, filterId, bf)
+	} else if tf, ok := filter.(*dbt.TransactionFilter); ok {
+		return h.StoreTransactionFilter(ctx, filterId, tf)
+	} else if lf, ok := filter.(*dbt.LogFilter); ok {
+		return h.StoreLogFilter(ctx, filterId, lf)
+	}
+	return errors.New("unknown filter type")
+}
+
+func (h *FilterHandler) StoreBlockFilter(ctx context.Context, filterId primitives.Data32, filter *dbt.BlockFilter) error {
+	return h.store(ctx, filterId, blockFilterType, filter)
+}
+
+func (h *FilterHandler) StoreTransactionFilter(ctx context.Context, filterId primitives.Data32, filter *dbt.TransactionFilter) error {
+	return h.store(ctx, filterId, transactionFilterType, filter)
+}
+
+func (h *FilterHandler) StoreLogFilter(ctx context.Context, filterId primitives.Data32, filter *dbt.LogFilter) error {
+	return h.store(ctx, filterId, logFilterType, filter)
+}
+
+func (h *FilterHandler) DeleteFilter(ctx context.Context, filterId primitives.Data32) error {
+	n, err := h.client.Del(ctx, h.filterKey(ctx, filterId)).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errFilterNotFound
+	}
+	return nil
+}
+
+func (h *FilterHandler) Close() error {
+	return h.client.Close()
+}
+
+// store writes the filter prefixed with its type, the key expires after the TTL unless the filter is stored again
+func (h *FilterHandler) store(ctx context.Context, filterId primitives.Data32, filterType byte, filter any) error {
+	data, err := h.codec.Marshal(filter)
+	if err != nil {
+		return err
+	}
+	value := append([]byte{filterType}, data...)
+	// a zero expiration keeps the key forever, as the other handlers do for a non-positive TTL
+	ttl := h.ttl
+	if ttl < 0 {
+		ttl = 0
+	}
+	return h.client.Set(ctx, h.filterKey(ctx, filterId), value, ttl).Err()
+}
+
+func (h *FilterHandler) filterKey(ctx context.Context, filterId primitives.Data32) string {
+	return fmt.Sprintf("%s:filter:%d:%s", h.Config.KeyPrefix, utils.GetChainId(ctx), filterId.Hex())
+}
+
+// getFilter reads the filter of type T, filters of the other types are reported as missing
+func getFilter[T any](h *FilterHandler, ctx context.Context, filterId primitives.Data32, filterType byte) (*T, error) {
+	data, err := h.client.Get(ctx, h.filterKey(ctx, filterId)).Bytes()
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if len(data) == 0 || data[0] != filterType {
+		return nil, errFilterNotFound
+	}
+	return decode[T](h, data[1:])
+}
+
+func decode[T any](h *FilterHandler, data []byte) (*T, error) {
+	filter := new(T)
+	if err := h.codec.Unmarshal(data, filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, goredis.Nil) {
+		return errFilterNotFound
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/db/codec"
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterHandler(t *testing.T) {
+	server := miniredis.RunT(t)
+	config := &Config{Addr: server.Addr(), KeyPrefix: "test", FilterTtlMinutes: 15}
+	// two handlers connected to the same server stand for two relayer replicas
+	h1, err := NewFilterHandlerWithConfig(config, codec.NewTinypackCodec())
+	require.NoError(t, err)
+	defer h1.Close()
+	h2, err := NewFilterHandlerWithConfig(config, codec.NewTinypackCodec())
+	require.NoError(t, err)
+	defer h2.Close()
+
+	ctx := utils.PutChainId(context.Background(), 1313161554)
+	blockId := primitives.MustData32FromHex("0x1")
+	txId := primitives.MustData32FromHex("0x2")
+	logId := primitives.MustData32FromHex("0x3")
+
+	bf := &dbt.BlockFilter{From: dbt.BlockKey{Height: 5}, Next: dbt.BlockKey{Height: 5}, LastPolledAt: 1}
+	lf := &dbt.LogFilter{From: dbt.LogKey{BlockHeight: 5}}
+	lf.Addresses.Content = []primitives.Data20{primitives.MustData20FromHex("0x11")}
+	require.NoError(t, h1.StoreFilter(ctx, blockId, bf))
+	require.NoError(t, h1.StoreTransactionFilter(ctx, txId, &dbt.TransactionFilter{LastPolledAt: 2}))
+	require.NoError(t, h1.StoreLogFilter(ctx, logId, lf))
+
+	gotBf, err := h2.GetBlockFilter(ctx, blockId)
+	require.NoError(t, err)
+	assert.Equal(t, bf.From, gotBf.From)
+	assert.Equal(t, bf.Next, gotBf.Next)
+	assert.Equal(t, bf.LastPolledAt, gotBf.LastPolledAt)
+	got, err := h2.GetFilter(ctx, txId)
+	require.NoError(t, err)
+	require.IsType(t, &dbt.TransactionFilter{}, got)
+	assert.Equal(t, uint64(2), got.(*dbt.TransactionFilter).LastPolledAt)
+	gotLf, err := h2.GetLogFilter(ctx, logId)
+	require.NoError(t, err)
+	assert.Equal(t, lf.Addresses.Content, gotLf.Addresses.Content)
+
+	_, err = h2.GetLogFilter(ctx, blockId)
+	assert.Error(t, err, "filter of another type")
+	_, err = h2.GetFilter(utils.PutChainId(context.Background(), 1), blockId)
+	assert.Error(t, err, "filter of another chain")
+
+	// the TTL is set natively and refreshed when the filter is stored back
+	server.FastForward(10 * time.Minute)
+	gotBf.Next = dbt.BlockKey{Height: 7}
+	require.NoError(t, h2.StoreFilter(ctx, blockId, gotBf))
+	server.FastForward(10 * time.Minute)
+	gotBf, err = h1.GetBlockFilter(ctx, blockId)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), gotBf.Next.Height)
+	_, err = h1.GetFilter(ctx, txId)
+	assert.Error(t, err, "expired filter")
+
+	require.NoError(t, h2.DeleteFilter(ctx, blockId))
+	assert.Error(t, h1.DeleteFilter(ctx, blockId))
+	_, err = h1.GetFilter(ctx, blockId)
+	assert.Error(t, err)
+}
+
+func TestFilterHandlerUnreachable(t *testing.T) {
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+	_, err := NewFilterHandlerWithConfig(&Config{Addr: addr}, codec.NewTinypackCodec())
+	assert.Error(t, err)
+}
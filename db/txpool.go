@@ -0,0 +1,36 @@
+package db
+
+import (
+	"github.com/aurora-is-near/relayer2-base/txpool"
+	"github.com/aurora-is-near/relayer2-base/types/indexer"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+)
+
+// TxPoolBlockHandler decorates a BlockHandler so that the transactions of the inserted blocks are evicted from the
+// pending transaction pool.
+type TxPoolBlockHandler struct {
+	BlockHandler
+	Pool *txpool.Pool
+}
+
+func NewTxPoolBlockHandler(bh BlockHandler, pool *txpool.Pool) *TxPoolBlockHandler {
+	return &TxPoolBlockHandler{
+		BlockHandler: bh,
+		Pool:         pool,
+	}
+}
+
+// InsertBlock inserts the block and, once it is written, evicts its transactions from the pool
+func (h *TxPoolBlockHandler) InsertBlock(block *indexer.Block) error {
+	if err := h.BlockHandler.InsertBlock(block); err != nil {
+		return err
+	}
+	if len(block.Transactions) > 0 {
+		hashes := make([]primitives.Data32, 0, len(block.Transactions))
+		for _, tx := range block.Transactions {
+			hashes = append(hashes, tx.Hash)
+		}
+		h.Pool.Remove(block.ChainId, hashes...)
+	}
+	return nil
+}
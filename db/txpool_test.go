@@ -0,0 +1,34 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aurora-is-near/relayer2-base/txpool"
+	"github.com/aurora-is-near/relayer2-base/types/indexer"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxPoolBlockHandlerInsertBlock(t *testing.T) {
+	bh := &stubBlockHandler{}
+	pool := txpool.NewWithConfig(&txpool.Config{})
+	h := NewTxPoolBlockHandler(bh, pool)
+
+	included := primitives.MustData32FromHex("0x1")
+	pending := primitives.MustData32FromHex("0x2")
+	pool.Add(1, included)
+	pool.Add(1, pending)
+
+	block := &indexer.Block{ChainId: 1, Height: 7, Transactions: []*indexer.Transaction{{Hash: included}}}
+	bh.insertErr = errors.New("insert failed")
+	require.Error(t, h.InsertBlock(block))
+	assert.Len(t, pool.Pending(1, 0), 2, "transactions of failed insertions must stay pending")
+
+	bh.insertErr = nil
+	require.NoError(t, h.InsertBlock(block))
+	txs := pool.Pending(1, 0)
+	require.Len(t, txs, 1)
+	assert.Equal(t, pending, txs[0].Hash)
+}
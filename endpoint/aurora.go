@@ -1,12 +1,20 @@
 package endpoint
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/aurora-is-near/relayer2-base/rpc"
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+	errs "github.com/aurora-is-near/relayer2-base/types/errors"
 	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/types/request"
+	"github.com/aurora-is-near/relayer2-base/types/response"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/valyala/fasthttp"
 )
@@ -74,3 +82,73 @@ func (a *AuroraRPC) MaxPriorityFeePerGas(chainId uint64) (*primitives.Quantity,
 
 	return a.maxPriorityFeePerGasCache, nil
 }
+
+// Aurora serves the relayer specific extensions of the eth API, it should be registered under the "aurora" namespace
+type Aurora struct {
+	*Endpoint
+}
+
+func NewAurora(endpoint *Endpoint) *Aurora {
+	return &Aurora{endpoint}
+}
+
+// GetLogsPage returns the logs for the given filter like eth_getLogs, but instead of failing when the response size
+// limits are exceeded, returns the logs read so far along with a cursor. Passing the cursor back with the same filter
+// returns the next page, the cursor of the last page is null.
+//
+//	If API is disabled, returns errors code '-32601' with message 'the method does not exist/is not available'.
+//	On filter option or cursor parsing failure, returns errors code '-32602' with custom message.
+//	On DB failure, returns errors code '-32000' with custom message.
+func (a *Aurora) GetLogsPage(ctx context.Context, rawFilter request.Filter, cursor *string) (*response.LogsPage, error) {
+	filter, err := a.parseRequestFilter(ctx, &rawFilter)
+	if err != nil {
+		return nil, &errs.InvalidParamsError{Message: err.Error()}
+	}
+	dbf := filter.ToLogFilter()
+	if cursor != nil {
+		next, err := decodeLogsCursor(*cursor)
+		if err != nil {
+			return nil, &errs.InvalidParamsError{Message: err.Error()}
+		}
+		if next.CompareTo(&dbf.From) < 0 {
+			return nil, &errs.InvalidParamsError{Message: "cursor is out of the filter range"}
+		}
+		dbf.From = *next
+	}
+
+	logs, next, err := a.DbHandler.GetLogsPage(ctx, dbf)
+	if err != nil {
+		return nil, &errs.GenericError{Err: err}
+	}
+	page := response.LogsPage{Logs: logs}
+	if page.Logs == nil {
+		page.Logs = []*response.Log{}
+	}
+	if next != nil {
+		c := encodeLogsCursor(next)
+		page.Cursor = &c
+	}
+	return &page, nil
+}
+
+const logsCursorLen = 3 * 8
+
+func encodeLogsCursor(key *dbt.LogKey) string {
+	buf := make([]byte, logsCursorLen)
+	binary.BigEndian.PutUint64(buf[0:], key.BlockHeight)
+	binary.BigEndian.PutUint64(buf[8:], key.TransactionIndex)
+	binary.BigEndian.PutUint64(buf[16:], key.LogIndex)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func decodeLogsCursor(cursor string) (*dbt.LogKey, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(buf) != logsCursorLen {
+		return nil, errors.New("invalid cursor")
+	}
+	return &dbt.LogKey{
+		BlockHeight:      binary.BigEndian.Uint64(buf[0:]),
+		TransactionIndex: binary.BigEndian.Uint64(buf[8:]),
+		LogIndex:         binary.BigEndian.Uint64(buf[16:]),
+	}, nil
+}
@@ -0,0 +1,22 @@
+package endpoint
+
+import (
+	"github.com/aurora-is-near/relayer2-base/types/request"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+
+	"golang.org/x/net/context"
+)
+
+type AuroraProcessorAware struct {
+	*Aurora
+}
+
+func NewAuroraProcessorAware(a *Aurora) *AuroraProcessorAware {
+	return &AuroraProcessorAware{a}
+}
+
+func (e *AuroraProcessorAware) GetLogsPage(ctx context.Context, filter request.Filter, cursor *string) (*response.LogsPage, error) {
+	return Process(ctx, "aurora_getLogsPage", e.Endpoint, func(ctx context.Context) (*response.LogsPage, error) {
+		return e.Aurora.GetLogsPage(ctx, filter, cursor)
+	}, filter, cursor)
+}
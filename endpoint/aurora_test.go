@@ -0,0 +1,83 @@
+package endpoint
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aurora-is-near/relayer2-base/db"
+	"github.com/aurora-is-near/relayer2-base/db/badger"
+	"github.com/aurora-is-near/relayer2-base/types/common"
+	"github.com/aurora-is-near/relayer2-base/types/indexer"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/types/request"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLogsPage(t *testing.T) {
+	viper.SetConfigType("yml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(ethTestYaml)))
+
+	bh, err := badger.NewBlockHandler()
+	require.NoError(t, err)
+	fh, err := badger.NewFilterHandler()
+	require.NoError(t, err)
+	handler := db.StoreHandler{BlockHandler: bh, FilterHandler: fh}
+	defer handler.Close()
+
+	data20 := primitives.MustData20FromHex("0x11")
+	data32 := primitives.MustData32FromHex("0x22")
+	data256 := primitives.MustData256FromHex("0x33")
+	quantity := primitives.QuantityFromHex("0x44")
+	for height := uint64(1); height <= 4; height++ {
+		blockHash := primitives.MustData32FromHex(fmt.Sprintf("0x%064x", height))
+		require.NoError(t, bh.InsertBlock(&indexer.Block{
+			ChainId: 1313161554, Height: height, Hash: blockHash, ParentHash: data32, Miner: data20,
+			TransactionsRoot: data32, ReceiptsRoot: data32, StateRoot: data32,
+			GasLimit: quantity, GasUsed: quantity, LogsBloom: data256,
+			Transactions: []*indexer.Transaction{{
+				BlockHash: blockHash, Hash: primitives.MustData32FromHex(fmt.Sprintf("0x%064x", height<<8)),
+				From: data20, Nonce: quantity, GasPrice: quantity, GasLimit: quantity, MaxFeePerGas: quantity,
+				MaxPriorityFeePerGas: quantity, Value: quantity, S: quantity, R: quantity,
+				NearTransaction: indexer.NearTransaction{ReceiptHash: indexer.NearHash(data32)}, LogsBloom: data256,
+				Logs: []*indexer.Log{{Address: data20}, {Address: data20}},
+			}},
+		}))
+	}
+	// force paging with 3 logs per page
+	bh.Config.Core.ScanRangeThreshold = 0
+	bh.Config.Core.MaxScanIterators = 3
+
+	aurora := NewAurora(New(handler))
+	from := common.IntToBN64(1)
+	to := common.IntToBN64(4)
+	filter := request.Filter{FromBlock: &from, ToBlock: &to}
+
+	var logs []*response.Log
+	var pages int
+	var cursor *string
+	for {
+		page, err := aurora.GetLogsPage(context.Background(), filter, cursor)
+		require.NoError(t, err)
+		logs = append(logs, page.Logs...)
+		pages++
+		if page.Cursor == nil {
+			break
+		}
+		cursor = page.Cursor
+	}
+	assert.Equal(t, 3, pages)
+	require.Len(t, logs, 8)
+	for i, l := range logs {
+		assert.Equal(t, primitives.HexUint(i/2+1), l.BlockNumber)
+		assert.Equal(t, primitives.HexUint(i%2), l.LogIndex)
+	}
+
+	invalid := "not a cursor"
+	_, err = aurora.GetLogsPage(context.Background(), filter, &invalid)
+	assert.Error(t, err)
+}
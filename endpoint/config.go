@@ -3,6 +3,8 @@ package endpoint
 import (
 	"fmt"
 	"math/big"
+	"strings"
+	"time"
 
 	"github.com/aurora-is-near/relayer2-base/cmdutils"
 	"github.com/aurora-is-near/relayer2-base/log"
@@ -22,6 +24,9 @@ const (
 	DepositForNearTxsCallDefault         = 0
 	retryWaitTimeMsForNearTxsCallDefault = 3000
 	retryNumberForNearTxsCallDefault     = 3
+	proxyUrlDefault                      = "https://testnet.aurora.dev:443"
+	proxyTimeoutMsDefault                = 10000
+	proxyMaxConnsDefault                 = 512
 )
 
 type EthConfig struct {
@@ -33,11 +38,14 @@ type EthConfig struct {
 }
 
 type Config struct {
-	ProxyUrl          string
-	ProxyEndpoints    map[string]bool `mapstructure:"proxyEndpoints"`
-	DisabledEndpoints map[string]bool `mapstructure:"disabledEndpoints"`
-	EthConfig         EthConfig       `mapstructure:"eth"`
-	EngineConfig      EngineConfig    `mapstructure:"engine"`
+	ProxyUrl              string
+	ProxyEndpoints        map[string]bool `mapstructure:"proxyEndpoints"`
+	ProxyTimeout          time.Duration
+	ProxyEndpointTimeouts map[string]time.Duration
+	ProxyMaxConns         int
+	DisabledEndpoints     map[string]bool `mapstructure:"disabledEndpoints"`
+	EthConfig             EthConfig       `mapstructure:"eth"`
+	EngineConfig          EngineConfig    `mapstructure:"engine"`
 }
 
 type ethConfig struct {
@@ -83,8 +91,11 @@ type engineConfig struct {
 }
 
 type proxyConfig struct {
-	Url       string   `mapstructure:"url"`
-	Endpoints []string `mapstructure:"endpoints"`
+	Url                string         `mapstructure:"url"`
+	Endpoints          []string       `mapstructure:"endpoints"`
+	TimeoutMs          int            `mapstructure:"timeoutMs"`
+	EndpointTimeoutsMs map[string]int `mapstructure:"endpointTimeoutsMs"`
+	MaxConns           int            `mapstructure:"maxConns"`
 }
 
 type config struct {
@@ -97,8 +108,11 @@ type config struct {
 func defaultConfig() *config {
 	return &config{
 		ProxyConfig: proxyConfig{
-			Url:       "https://testnet.aurora.dev:443",
-			Endpoints: []string{},
+			Url:                proxyUrlDefault,
+			Endpoints:          []string{},
+			TimeoutMs:          proxyTimeoutMsDefault,
+			EndpointTimeoutsMs: map[string]int{},
+			MaxConns:           proxyMaxConnsDefault,
 		},
 		DisabledEndpoints: []string{},
 		EthConfig: ethConfig{
@@ -162,9 +176,12 @@ func GetConfig() *Config {
 			RetryWaitTimeMsForNearTxsCall: c.EngineConfig.RetryWaitTimeMsForNearTxsCall,
 			RetryNumberForNearTxsCall:     c.EngineConfig.RetryNumberForNearTxsCall,
 		},
-		DisabledEndpoints: make(map[string]bool, len(c.DisabledEndpoints)),
-		ProxyEndpoints:    make(map[string]bool, len(c.ProxyConfig.Endpoints)),
-		ProxyUrl:          c.ProxyConfig.Url,
+		DisabledEndpoints:     make(map[string]bool, len(c.DisabledEndpoints)),
+		ProxyEndpoints:        make(map[string]bool, len(c.ProxyConfig.Endpoints)),
+		ProxyUrl:              c.ProxyConfig.Url,
+		ProxyTimeout:          time.Duration(c.ProxyConfig.TimeoutMs) * time.Millisecond,
+		ProxyEndpointTimeouts: make(map[string]time.Duration, len(c.ProxyConfig.EndpointTimeoutsMs)),
+		ProxyMaxConns:         c.ProxyConfig.MaxConns,
 	}
 
 	for _, de := range c.DisabledEndpoints {
@@ -175,5 +192,18 @@ func GetConfig() *Config {
 		config.ProxyEndpoints[pe] = true
 	}
 
+	// viper lower cases the map keys, so does the lookup in ProxyTimeoutFor
+	for pe, ms := range c.ProxyConfig.EndpointTimeoutsMs {
+		config.ProxyEndpointTimeouts[strings.ToLower(pe)] = time.Duration(ms) * time.Millisecond
+	}
+
 	return config
 }
+
+// ProxyTimeoutFor returns the upstream timeout of the given proxied endpoint
+func (c *Config) ProxyTimeoutFor(name string) time.Duration {
+	if t, ok := c.ProxyEndpointTimeouts[strings.ToLower(name)]; ok {
+		return t
+	}
+	return c.ProxyTimeout
+}
@@ -3,6 +3,8 @@ package endpoint
 import (
 	"github.com/aurora-is-near/relayer2-base/db"
 	"github.com/aurora-is-near/relayer2-base/log"
+	"github.com/aurora-is-near/relayer2-base/txpool"
+	"github.com/aurora-is-near/relayer2-base/utils"
 	jsoniter "github.com/json-iterator/go"
 
 	"golang.org/x/net/context"
@@ -23,6 +25,8 @@ func Process[T any](ctx context.Context, name string, endpoint *Endpoint, handle
 		if stop {
 			if err != nil {
 				return nil, err
+			} else if resp == nil {
+				return nil, nil
 			} else {
 				if r, ok := resp.(T); ok {
 					return &r, nil
@@ -60,6 +64,9 @@ type Endpoint struct {
 	Config        *Config
 	WithProcessor func(Processor)
 	Processors    []Processor
+	// TxPool is set by the relayers which record the submitted transactions, the pending transaction endpoints return
+	// empty results without it
+	TxPool *txpool.Pool
 }
 
 func New(dbh db.Handler) *Endpoint {
@@ -84,6 +91,20 @@ func withProcessor(e *Endpoint, p Processor) {
 	e.Processors = append(e.Processors, p)
 }
 
+// pendingTransactions returns the hashes of up to limit pending transactions of the chain in the request context, all
+// of them if limit is not positive
+func (e *Endpoint) pendingTransactions(ctx context.Context, limit int) *[]string {
+	if e.TxPool == nil {
+		return utils.Constants.EmptyArray()
+	}
+	txs := e.TxPool.Pending(utils.GetChainId(ctx), limit)
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash.Hex())
+	}
+	return &hashes
+}
+
 func (e *Endpoint) HandleConfigChange() {
 	e.Config = GetConfig()
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/aurora-is-near/relayer2-base/indexer/progress"
 	"github.com/aurora-is-near/relayer2-base/log"
 	"github.com/aurora-is-near/relayer2-base/tweaks"
 	utils2 "github.com/aurora-is-near/relayer2-base/types/utils"
@@ -70,11 +71,21 @@ func (e *Eth) Mining(_ context.Context) (*bool, error) {
 	return utils.Constants.Mining(), nil
 }
 
-// Syncing returns constant false
+// Syncing returns false if all the indexers reached their targets, otherwise returns the progress of the indexer
+// furthest behind its target as startingBlock, currentBlock and highestBlock.
 //
 //	If API is disabled, returns errors code '-32601' with message 'the method does not exist/is not available'.
-func (e *Eth) Syncing(_ context.Context) (*bool, error) {
-	return utils.Constants.Syncing(), nil
+func (e *Eth) Syncing(_ context.Context) (*response.SyncStatus, error) {
+	s := progress.Syncing()
+	if s == nil {
+		return &response.SyncStatus{Syncing: false}, nil
+	}
+	return &response.SyncStatus{
+		Syncing:       true,
+		StartingBlock: primitives.HexUint(s.Start),
+		CurrentBlock:  primitives.HexUint(s.Current),
+		HighestBlock:  primitives.HexUint(s.Target),
+	}, nil
 }
 
 // BlockNumber returns the latest block number from DB if API is enabled by configuration.
@@ -224,6 +235,24 @@ func (e *Eth) GetTransactionReceipt(ctx context.Context, hash common.H256) (*res
 	return txsReceipt, nil
 }
 
+// GetBlockReceipts returns the receipts of all the transactions of the block with the given block number or hash.
+//
+//	If API is disabled, returns errors code '-32601' with message 'the method does not exist/is not available'.
+//	On missing or invalid param returns error code '-32602' with custom message.
+//	If block not found (KeyNotFoundError) returns nil
+//	On DB failure or other internal errors, returns errors code '-32000' with custom message.
+func (e *Eth) GetBlockReceipts(ctx context.Context, blockNumberOrHash common.BlockNumberOrHash) (*[]*response.TransactionReceipt, error) {
+	receipts, err := e.DbHandler.GetBlockReceipts(ctx, blockNumberOrHash)
+	if err != nil {
+		_, ok := err.(*errs.KeyNotFoundError)
+		if !ok {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return &receipts, nil
+}
+
 // NewFilter creates a new filter based on the filter options and returns newly created filter ID on success.
 //
 // FilterOptions object is mandatory but all keys are optional
@@ -274,11 +303,19 @@ func (e *Eth) NewBlockFilter(ctx context.Context) (*common.Uint256, error) {
 	return &fid, nil
 }
 
-// NewPendingTransactionFilter returns empty array
+// NewPendingTransactionFilter creates a filter returning the hashes of the txs submitted by the relayer since the
+// previous poll and returns newly created filter ID. Returns zero filter ID if the relayer doesn't track its submitted
+// txs (see Endpoint.TxPool).
 //
 //	If API is disabled, returns errors code '-32601' with message 'the method does not exist/is not available'.
-func (e *Eth) NewPendingTransactionFilter(_ context.Context) (*string, error) {
-	return utils.Constants.ZeroStrUint128(), nil
+func (e *Eth) NewPendingTransactionFilter(ctx context.Context) (*common.Uint256, error) {
+	if e.TxPool == nil {
+		fid := common.IntToUint256(0)
+		return &fid, nil
+	}
+	fid := common.RandomUint256()
+	e.TxPool.NewFilter(utils.GetChainId(ctx), fid.Data32())
+	return &fid, nil
 }
 
 // UninstallFilter deletes a filter with given filter id and returns true on success. Additionally, filters timeout when
@@ -289,6 +326,9 @@ func (e *Eth) NewPendingTransactionFilter(_ context.Context) (*string, error) {
 func (e *Eth) UninstallFilter(ctx context.Context, filterId common.Uint256) (*bool, error) {
 	var err error
 	resp := true
+	if e.TxPool != nil && e.TxPool.UninstallFilter(utils.GetChainId(ctx), filterId.Data32()) {
+		return &resp, nil
+	}
 	err = e.DbHandler.DeleteFilter(ctx, filterId.Data32())
 	if err != nil {
 		resp = false
@@ -302,6 +342,15 @@ func (e *Eth) UninstallFilter(ctx context.Context, filterId common.Uint256) (*bo
 //	On failure, returns errors code '-32000' with custom message.
 func (e *Eth) GetFilterChanges(ctx context.Context, filterId common.Uint256) (*[]interface{}, error) {
 	fid := filterId.Data32()
+	if e.TxPool != nil {
+		if txs, ok := e.TxPool.FilterChanges(utils.GetChainId(ctx), fid); ok {
+			hashes := make([]interface{}, 0, len(txs))
+			for _, tx := range txs {
+				hashes = append(hashes, tx.Hash)
+			}
+			return &hashes, nil
+		}
+	}
 	filter, err := e.DbHandler.GetFilter(ctx, fid)
 	if err != nil {
 		return nil, &errs.GenericError{Err: err}
@@ -414,11 +463,12 @@ func (e *Eth) GetCompilers(_ context.Context) (*[]string, error) {
 	return utils.Constants.EmptyArray(), nil
 }
 
-// PendingTransactions returns empty array
+// PendingTransactions returns the hashes of the txs submitted by the relayer which are not included in a block yet.
+// Returns empty array if the relayer doesn't track its submitted txs (see Endpoint.TxPool).
 //
 //	If API is disabled, returns errors code '-32601' with message 'the method does not exist/is not available'.
-func (e *Eth) PendingTransactions(_ context.Context) (*[]string, error) {
-	return utils.Constants.EmptyArray(), nil
+func (e *Eth) PendingTransactions(ctx context.Context) (*[]string, error) {
+	return e.pendingTransactions(ctx, 0), nil
 }
 
 // EstimateGas returns constant gas estimation provided in configuration file.
@@ -438,7 +488,7 @@ func (e *Eth) GasPrice(_ context.Context) (*common.Uint256, error) {
 	return &e.Config.EthConfig.GasPrice, nil
 }
 
-func (e *Eth) parseRequestFilter(ctx context.Context, filter *request.Filter) (*types.Filter, error) {
+func (e *Endpoint) parseRequestFilter(ctx context.Context, filter *request.Filter) (*types.Filter, error) {
 
 	f := &types.Filter{}
 	if filter.BlockHash != nil {
@@ -48,8 +48,8 @@ func (e *EthProcessorAware) Mining(ctx context.Context) (*bool, error) {
 	})
 }
 
-func (e *EthProcessorAware) Syncing(ctx context.Context) (*bool, error) {
-	return Process(ctx, "eth_syncing", e.Endpoint, func(ctx context.Context) (*bool, error) {
+func (e *EthProcessorAware) Syncing(ctx context.Context) (*response.SyncStatus, error) {
+	return Process(ctx, "eth_syncing", e.Endpoint, func(ctx context.Context) (*response.SyncStatus, error) {
 		return e.Eth.Syncing(ctx)
 	})
 }
@@ -108,6 +108,12 @@ func (e *EthProcessorAware) GetTransactionReceipt(ctx context.Context, hash comm
 	}, hash)
 }
 
+func (e *EthProcessorAware) GetBlockReceipts(ctx context.Context, blockNumberOrHash common.BlockNumberOrHash) (*[]*response.TransactionReceipt, error) {
+	return Process(ctx, "eth_getBlockReceipts", e.Endpoint, func(ctx context.Context) (*[]*response.TransactionReceipt, error) {
+		return e.Eth.GetBlockReceipts(ctx, blockNumberOrHash)
+	}, blockNumberOrHash)
+}
+
 func (e *EthProcessorAware) GetLogs(ctx context.Context, rawFilter request.Filter) (*[]*response.Log, error) {
 	return Process(ctx, "eth_getLogs", e.Endpoint, func(ctx context.Context) (*[]*response.Log, error) {
 		return e.Eth.GetLogs(ctx, rawFilter)
@@ -156,8 +162,8 @@ func (e *EthProcessorAware) NewBlockFilter(ctx context.Context) (*common.Uint256
 	})
 }
 
-func (e *EthProcessorAware) NewPendingTransactionFilter(ctx context.Context) (*string, error) {
-	return Process(ctx, "eth_newPendingTransactionFilter", e.Endpoint, func(ctx context.Context) (*string, error) {
+func (e *EthProcessorAware) NewPendingTransactionFilter(ctx context.Context) (*common.Uint256, error) {
+	return Process(ctx, "eth_newPendingTransactionFilter", e.Endpoint, func(ctx context.Context) (*common.Uint256, error) {
 		return e.Eth.NewPendingTransactionFilter(ctx)
 	})
 }
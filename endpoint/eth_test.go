@@ -8,11 +8,13 @@ import (
 
 	"github.com/aurora-is-near/relayer2-base/db"
 	"github.com/aurora-is-near/relayer2-base/db/badger"
+	"github.com/aurora-is-near/relayer2-base/indexer/progress"
 	"github.com/aurora-is-near/relayer2-base/types"
 	"github.com/aurora-is-near/relayer2-base/types/common"
 	"github.com/aurora-is-near/relayer2-base/types/indexer"
 	"github.com/aurora-is-near/relayer2-base/types/primitives"
 	"github.com/aurora-is-near/relayer2-base/types/request"
+	"github.com/aurora-is-near/relayer2-base/types/response"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -269,3 +271,25 @@ func TestTopicsUnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncing(t *testing.T) {
+	eth := &Eth{}
+	s, err := eth.Syncing(context.Background())
+	assert.Nil(t, err)
+	buf, err := jsoniter.Marshal(s)
+	assert.Nil(t, err)
+	assert.Equal(t, "false", string(buf))
+
+	tracker := progress.Track("test", 10, 100)
+	defer tracker.Done()
+	tracker.Update(40)
+	s, err = eth.Syncing(context.Background())
+	assert.Nil(t, err)
+	buf, err = jsoniter.Marshal(s)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"startingBlock":"0xa","currentBlock":"0x28","highestBlock":"0x64"}`, string(buf))
+
+	var decoded response.SyncStatus
+	assert.Nil(t, jsoniter.Unmarshal(buf, &decoded))
+	assert.Equal(t, *s, decoded)
+}
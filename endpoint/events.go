@@ -0,0 +1,368 @@
+package endpoint
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aurora-is-near/relayer2-base/broker"
+	"github.com/aurora-is-near/relayer2-base/db"
+	"github.com/aurora-is-near/relayer2-base/log"
+	"github.com/aurora-is-near/relayer2-base/rpc"
+	"github.com/aurora-is-near/relayer2-base/types"
+	"github.com/aurora-is-near/relayer2-base/types/common"
+	errs "github.com/aurora-is-near/relayer2-base/types/errors"
+	"github.com/aurora-is-near/relayer2-base/types/event"
+	"github.com/aurora-is-near/relayer2-base/types/request"
+	"github.com/aurora-is-near/relayer2-base/utils"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	newHeadsChSize            = 16
+	logsChSize                = 16
+	pendingTransactionsChSize = 64
+	defaultMaxReplayBlocks    = 10000
+	defaultMaxReplayQueue     = 1024
+)
+
+var (
+	errNotificationsUnsupported = errors.New("notifications not supported")
+	errReplayUnsupported        = errors.New("fromBlock not supported")
+)
+
+// Events serves the eth_subscribe subscriptions from the events published to the broker, it should be registered
+// with rpc.RpcServer.RegisterEvents under the "eth" namespace
+type Events struct {
+	Broker broker.Broker
+	Logger *log.Logger
+	// BlockHandler serves the replay of the subscriptions given a fromBlock, which is rejected if it is not set
+	BlockHandler db.BlockHandler
+	// MaxReplayBlocks limits how far behind the latest block fromBlock can be
+	MaxReplayBlocks uint64
+	// MaxReplayQueue limits the number of events published during a replay which are queued until it completes, the
+	// subscription is closed as a slow consumer once it is exceeded
+	MaxReplayQueue int
+}
+
+func NewEvents(b broker.Broker) *Events {
+	return &Events{
+		Broker:          b,
+		Logger:          log.Log(),
+		MaxReplayBlocks: defaultMaxReplayBlocks,
+		MaxReplayQueue:  defaultMaxReplayQueue,
+	}
+}
+
+// NewHeads sends a notification for each new block until the client unsubscribes, the connection is closed or the
+// broker closes the subscription since the client is too slow. If fromBlock is given, the heads from that block up to
+// the latest one are read from the DB and sent first, the subscription is closed with an error if the replay fails.
+func (e *Events) NewHeads(ctx context.Context, opts *request.NewHeadsSubscriptionOptions) (*rpc.ID, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, errNotificationsUnsupported
+	}
+	if opts == nil {
+		opts = &request.NewHeadsSubscriptionOptions{}
+	}
+
+	heads := make(chan event.Block, newHeadsChSize)
+	sub := e.Broker.SubscribeNewHeads(heads)
+	// the replay range is read after subscribing, so that every block inserted later is received from the broker
+	from, to, err := e.replayRange(ctx, opts.FromBlock)
+	if err != nil {
+		e.Broker.UnsubscribeFromNewHeads(sub)
+		return nil, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	// the replay outlives the request, it is stopped once the client unsubscribes instead
+	ctx = utils.WithoutCancel(ctx)
+	go func() {
+		defer e.Broker.UnsubscribeFromNewHeads(sub)
+		var queued []event.Block
+		if from != nil {
+			var replayed bool
+			if queued, replayed = replay(rpcSub, heads, e.MaxReplayQueue, func(stop <-chan struct{}) bool {
+				return e.replayHeads(ctx, notifier, rpcSub.ID, *from, *to, stop)
+			}, func() {
+				e.closeReplay(notifier, rpcSub.ID, "newHeads", &errs.SlowConsumerError{})
+			}); !replayed {
+				return
+			}
+		}
+		// heads published after the replay range is read may still be replayed, they are skipped until the first one
+		// beyond the range
+		caughtUp := from == nil
+		send := func(h event.Block) {
+			if !caughtUp {
+				if uint64(h.Number) <= *to {
+					return
+				}
+				caughtUp = true
+			}
+			e.notify(notifier, rpcSub.ID, "newHeads", h)
+		}
+		for _, h := range queued {
+			send(h)
+		}
+		for {
+			select {
+			case h := <-heads:
+				send(h)
+			case err := <-sub.Err():
+				e.close(notifier, rpcSub.ID, "newHeads", err)
+				return
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return &rpcSub.ID, nil
+}
+
+// Logs sends a notification for each published log matching the given address and topics until the client
+// unsubscribes or the connection is closed, logs of reverted blocks are sent again with `removed: true`. If fromBlock
+// is given, the matching logs from that block up to the latest one are read from the DB and sent first, the
+// subscription is closed with an error if the replay fails.
+func (e *Events) Logs(ctx context.Context, opts *request.LogSubscriptionOptions) (*rpc.ID, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, errNotificationsUnsupported
+	}
+	if opts == nil {
+		opts = &request.LogSubscriptionOptions{}
+	}
+
+	logs := make(chan event.Logs, logsChSize)
+	sub := e.Broker.SubscribeLogs(*opts, logs)
+	from, to, err := e.replayRange(ctx, opts.FromBlock)
+	if err != nil {
+		e.Broker.UnsubscribeFromLogs(sub)
+		return nil, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	// the replay outlives the request, it is stopped once the client unsubscribes instead
+	ctx = utils.WithoutCancel(ctx)
+	go func() {
+		defer e.Broker.UnsubscribeFromLogs(sub)
+		var queued []event.Logs
+		if from != nil {
+			var replayed bool
+			if queued, replayed = replay(rpcSub, logs, e.MaxReplayQueue, func(stop <-chan struct{}) bool {
+				return e.replayLogs(ctx, notifier, rpcSub.ID, opts, *from, *to, stop)
+			}, func() {
+				e.closeReplay(notifier, rpcSub.ID, "logs", &errs.SlowConsumerError{})
+			}); !replayed {
+				return
+			}
+		}
+		// logs published after the replay range is read may still be replayed, they are skipped until the first one
+		// beyond the range or the first removed one, which is always sent as the client may have received it
+		caughtUp := from == nil
+		send := func(ls event.Logs) {
+			for _, l := range ls {
+				if !caughtUp {
+					if !l.Removed && uint64(l.BlockNumber) <= *to {
+						continue
+					}
+					caughtUp = true
+				}
+				e.notify(notifier, rpcSub.ID, "logs", l)
+			}
+		}
+		for _, ls := range queued {
+			send(ls)
+		}
+		for {
+			select {
+			case ls := <-logs:
+				send(ls)
+			case err := <-sub.Err():
+				e.close(notifier, rpcSub.ID, "logs", err)
+				return
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return &rpcSub.ID, nil
+}
+
+// NewPendingTransactions sends a notification with the hash of each transaction submitted by the relayer to the chain
+// of the request until the client unsubscribes or the connection is closed, the whole transaction is sent instead if
+// fullTx is true and its details are known
+func (e *Events) NewPendingTransactions(ctx context.Context, fullTx *bool) (*rpc.ID, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, errNotificationsUnsupported
+	}
+	full := fullTx != nil && *fullTx
+	chainId := utils.GetChainId(ctx)
+
+	rpcSub := notifier.CreateSubscription()
+	txs := make(chan event.Transaction, pendingTransactionsChSize)
+	sub := e.Broker.SubscribePendingTransactions(txs)
+	go func() {
+		defer e.Broker.UnsubscribeFromPendingTransactions(sub)
+		for {
+			select {
+			case tx := <-txs:
+				if tx.ChainId != chainId {
+					continue
+				}
+				var data any = tx.Hash
+				if full && tx.Tx != nil {
+					data = tx.Tx
+				}
+				e.notify(notifier, rpcSub.ID, "newPendingTransactions", data)
+			case err := <-sub.Err():
+				e.close(notifier, rpcSub.ID, "newPendingTransactions", err)
+				return
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return &rpcSub.ID, nil
+}
+
+// WithBlockHandler enables the replay of the heads and logs from the given fromBlock
+func (e *Events) WithBlockHandler(bh db.BlockHandler) {
+	e.BlockHandler = bh
+}
+
+// replayRange returns the heights to replay, nil if fromBlock is not a block number
+func (e *Events) replayRange(ctx context.Context, fromBlock *common.BN64) (*uint64, *uint64, error) {
+	if fromBlock == nil {
+		return nil, nil, nil
+	}
+	bn := fromBlock.Uint64()
+	if bn == nil {
+		return nil, nil, nil
+	}
+	if e.BlockHandler == nil {
+		return nil, nil, errReplayUnsupported
+	}
+	// block "0x0" (earliest) is stored as "0x1", the height is copied as it may point into the request options
+	from := *bn
+	if from == 0 {
+		from = 1
+	}
+	latest, err := e.BlockHandler.BlockNumber(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	to := uint64(*latest)
+	if from > to {
+		return nil, nil, nil
+	}
+	if to-from >= e.MaxReplayBlocks {
+		return nil, nil, fmt.Errorf("fromBlock is more than %d blocks behind the latest block", e.MaxReplayBlocks)
+	}
+	return &from, &to, nil
+}
+
+// replay runs backfill while buffering up to limit live events received meanwhile, returns the buffered events or false
+// if the client unsubscribed, backfill failed or the buffer overflowed. On overflow, backfill is stopped and overflow
+// is called to close the subscription.
+func replay[T any](rpcSub *rpc.Subscription, live chan T, limit int, backfill func(stop <-chan struct{}) bool, overflow func()) ([]T, bool) {
+	stop := make(chan struct{})
+	done := make(chan bool, 1)
+	go func() {
+		done <- backfill(stop)
+	}()
+	var queued []T
+	for {
+		select {
+		case ev := <-live:
+			if len(queued) >= limit {
+				close(stop)
+				<-done
+				overflow()
+				return nil, false
+			}
+			queued = append(queued, ev)
+		case ok := <-done:
+			return queued, ok
+		case <-rpcSub.Err():
+			close(stop)
+			<-done
+			return nil, false
+		}
+	}
+}
+
+// replayHeads sends the heads of the given range, the subscription is closed with an error if any of them can't be
+// read, since the client would miss it otherwise. Returns false if the replay did not complete.
+func (e *Events) replayHeads(ctx context.Context, notifier *rpc.Notifier, id rpc.ID, from, to uint64, stop <-chan struct{}) bool {
+	for height := from; height <= to; height++ {
+		select {
+		case <-stop:
+			return false
+		default:
+		}
+		head, err := e.BlockHandler.GetBlockByNumber(ctx, common.BN64(height), false)
+		if err != nil {
+			e.Logger.Error().Err(err).Msgf("failed to replay block [%d] to newHeads subscription [%s]", height, id)
+			e.closeReplay(notifier, id, "newHeads", err)
+			return false
+		}
+		if head != nil {
+			e.notify(notifier, id, "newHeads", event.Block(head))
+		}
+	}
+	return true
+}
+
+// replayLogs sends the matching logs of the given range, the subscription is closed with an error if they can't be
+// read. Returns false if the replay did not complete.
+func (e *Events) replayLogs(ctx context.Context, notifier *rpc.Notifier, id rpc.ID, opts *request.LogSubscriptionOptions, from, to uint64, stop <-chan struct{}) bool {
+	f := &types.Filter{FromBlock: &from, ToBlock: &to, Topics: opts.Topics}
+	for _, a := range opts.Address {
+		f.Addresses = append(f.Addresses, a.Data20)
+	}
+	filter := f.ToLogFilter()
+	for {
+		select {
+		case <-stop:
+			return false
+		default:
+		}
+		logs, next, err := e.BlockHandler.GetLogsPage(ctx, filter)
+		if err != nil {
+			e.Logger.Error().Err(err).Msgf("failed to replay logs from block [%d] to logs subscription [%s]", filter.From.BlockHeight, id)
+			e.closeReplay(notifier, id, "logs", err)
+			return false
+		}
+		for _, l := range logs {
+			e.notify(notifier, id, "logs", l)
+		}
+		if next == nil {
+			return true
+		}
+		filter.From = *next
+	}
+}
+
+// close ends the subscription closed by the broker, the client is notified with the error
+func (e *Events) close(notifier *rpc.Notifier, id rpc.ID, name string, err error) {
+	e.Logger.Warn().Err(err).Msgf("%s subscription [%s] closed by the broker", name, id)
+	if err := notifier.Close(id, err); err != nil {
+		e.Logger.Error().Err(err).Msgf("failed to close %s subscription [%s]", name, id)
+	}
+}
+
+// closeReplay ends the subscription whose replay failed, the client is notified with the error
+func (e *Events) closeReplay(notifier *rpc.Notifier, id rpc.ID, name string, err error) {
+	if err := notifier.Close(id, err); err != nil {
+		e.Logger.Error().Err(err).Msgf("failed to close %s subscription [%s]", name, id)
+	}
+}
+
+func (e *Events) notify(notifier *rpc.Notifier, id rpc.ID, name string, data any) {
+	if err := notifier.Notify(id, data); err != nil {
+		e.Logger.Error().Err(err).Msgf("failed to notify %s subscription [%s]", name, id)
+	}
+}
@@ -0,0 +1,408 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/broker"
+	"github.com/aurora-is-near/relayer2-base/db"
+	"github.com/aurora-is-near/relayer2-base/log"
+	"github.com/aurora-is-near/relayer2-base/rpc"
+	"github.com/aurora-is-near/relayer2-base/rpc/node/events"
+	"github.com/aurora-is-near/relayer2-base/types/common"
+	dbt "github.com/aurora-is-near/relayer2-base/types/db"
+	errs "github.com/aurora-is-near/relayer2-base/types/errors"
+	"github.com/aurora-is-near/relayer2-base/types/event"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+	"github.com/aurora-is-near/relayer2-base/utils"
+	"github.com/fasthttp/websocket"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type wsMessage struct {
+	Id     *int                `json:"id"`
+	Method string              `json:"method"`
+	Result jsoniter.RawMessage `json:"result"`
+	Params struct {
+		Subscription string              `json:"subscription"`
+		Result       jsoniter.RawMessage `json:"result"`
+		Error        *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"params"`
+}
+
+func startEventsServer(t *testing.T, opts ...func(*Events)) (*events.EventBroker, string) {
+	eb := events.NewEventBroker()
+	go eb.Start()
+	return eb, startEventsServerWithBroker(t, eb, opts...)
+}
+
+func startEventsServerWithBroker(t *testing.T, b broker.Broker, opts ...func(*Events)) string {
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	srv := rpc.New(log.Log(), 10, rpc.WithTransport(&rpc.HttpServer{
+		Logger: log.Log(),
+		Config: rpc.HttpConfig{
+			HttpEndpoint:       addr,
+			HttpPathPrefix:     "*",
+			HttpTimeout:        10,
+			WsEndpoint:         addr,
+			WsPathPrefix:       "*",
+			WsHandshakeTimeout: 10,
+		},
+	}))
+	ev := NewEvents(b)
+	for _, opt := range opts {
+		opt(ev)
+	}
+	require.NoError(t, srv.RegisterEvents("eth", ev))
+	require.NoError(t, srv.Run(context.Background()))
+	t.Cleanup(srv.Close)
+	return "ws://" + addr
+}
+
+func dialEvents(t *testing.T, url string) *websocket.Conn {
+	var conn *websocket.Conn
+	var err error
+	require.Eventually(t, func() bool {
+		conn, _, err = websocket.DefaultDialer.Dial(url, nil)
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) wsMessage {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	var msg wsMessage
+	require.NoError(t, jsoniter.Unmarshal(data, &msg))
+	return msg
+}
+
+func call(t *testing.T, conn *websocket.Conn, id int, method string, params string) wsMessage {
+	req := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"%s","params":%s}`, id, method, params)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(req)))
+	msg := readMessage(t, conn)
+	require.NotNil(t, msg.Id)
+	require.Equal(t, id, *msg.Id)
+	return msg
+}
+
+func subscriptionCount(eb *events.EventBroker, count func(events.Stats) int) func() int {
+	return func() int {
+		return count(eb.Stats())
+	}
+}
+
+func TestEventsNewHeads(t *testing.T) {
+	eb, url := startEventsServer(t)
+	newHeadsCount := subscriptionCount(eb, func(s events.Stats) int { return s.NewHeadsSubscriptions })
+	conn := dialEvents(t, url)
+	defer conn.Close()
+
+	var subId string
+	require.NoError(t, jsoniter.Unmarshal(call(t, conn, 1, "eth_subscribe", `["newHeads"]`).Result, &subId))
+	assert.Equal(t, 1, newHeadsCount())
+
+	eb.PublishNewHeads(event.Block(&response.Block{Number: 42}))
+	msg := readMessage(t, conn)
+	assert.Equal(t, "eth_subscription", msg.Method)
+	assert.Equal(t, subId, msg.Params.Subscription)
+	var head response.Block
+	require.NoError(t, jsoniter.Unmarshal(msg.Params.Result, &head))
+	assert.Equal(t, primitives.HexUint(42), head.Number)
+
+	assert.Equal(t, "true", string(call(t, conn, 2, "eth_unsubscribe", fmt.Sprintf(`["%s"]`, subId)).Result))
+	assert.Eventually(t, func() bool { return newHeadsCount() == 0 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "false", string(call(t, conn, 3, "eth_unsubscribe", fmt.Sprintf(`["%s"]`, subId)).Result))
+}
+
+func TestEventsLogs(t *testing.T) {
+	eb, url := startEventsServer(t)
+	logsCount := subscriptionCount(eb, func(s events.Stats) int { return s.LogsSubscriptions })
+	conn := dialEvents(t, url)
+
+	address := primitives.MustData20FromHex("0x0000000000000000000000000000000000000001")
+	other := primitives.MustData20FromHex("0x0000000000000000000000000000000000000002")
+	var subId string
+	params := fmt.Sprintf(`["logs",{"address":["%s"]}]`, address.Hex())
+	require.NoError(t, jsoniter.Unmarshal(call(t, conn, 1, "eth_subscribe", params).Result, &subId))
+	assert.Equal(t, 1, logsCount())
+
+	eb.PublishLogs(event.Logs{
+		{Address: other, LogIndex: 0},
+		{Address: address, LogIndex: 1},
+		{Address: address, LogIndex: 2, Removed: true},
+	})
+	for _, idx := range []primitives.HexUint{1, 2} {
+		msg := readMessage(t, conn)
+		assert.Equal(t, subId, msg.Params.Subscription)
+		var l response.Log
+		require.NoError(t, jsoniter.Unmarshal(msg.Params.Result, &l))
+		assert.Equal(t, address, l.Address)
+		assert.Equal(t, idx, l.LogIndex)
+		assert.Equal(t, idx == 2, l.Removed)
+	}
+
+	// closing the connection must unsubscribe from the broker
+	require.NoError(t, conn.Close())
+	assert.Eventually(t, func() bool { return logsCount() == 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestEventsUnknownSubscription(t *testing.T) {
+	_, url := startEventsServer(t)
+	conn := dialEvents(t, url)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newPendingHeads"]}`)))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"code":-32601`)
+}
+
+func TestEventsNewPendingTransactions(t *testing.T) {
+	eb, url := startEventsServer(t)
+	pendingCount := subscriptionCount(eb, func(s events.Stats) int { return s.PendingTransactionsSubscriptions })
+	conn := dialEvents(t, url)
+	defer conn.Close()
+
+	var hashSubId, fullSubId string
+	require.NoError(t, jsoniter.Unmarshal(call(t, conn, 1, "eth_subscribe", `["newPendingTransactions"]`).Result, &hashSubId))
+	require.NoError(t, jsoniter.Unmarshal(call(t, conn, 2, "eth_subscribe", `["newPendingTransactions",true]`).Result, &fullSubId))
+	assert.Equal(t, 2, pendingCount())
+
+	chainId := utils.GetChainId(context.Background())
+	// the transactions of the other chains are not sent
+	eb.PublishPendingTransaction(event.Transaction{ChainId: chainId + 1, Hash: primitives.MustData32FromHex("0x3")})
+	hash := primitives.MustData32FromHex("0x1")
+	eb.PublishPendingTransaction(event.Transaction{ChainId: chainId, Hash: hash, Tx: &response.Transaction{Hash: hash, Nonce: primitives.QuantityFromHex("0x7")}})
+	for i := 0; i < 2; i++ {
+		msg := readMessage(t, conn)
+		switch msg.Params.Subscription {
+		case hashSubId:
+			var got primitives.Data32
+			require.NoError(t, jsoniter.Unmarshal(msg.Params.Result, &got))
+			assert.Equal(t, hash, got)
+		case fullSubId:
+			var got response.Transaction
+			require.NoError(t, jsoniter.Unmarshal(msg.Params.Result, &got))
+			assert.Equal(t, hash, got.Hash)
+			assert.Equal(t, primitives.QuantityFromHex("0x7"), got.Nonce)
+		default:
+			t.Fatalf("unexpected subscription [%s]", msg.Params.Subscription)
+		}
+	}
+
+	// only the hash is sent if the details of the transaction are not known
+	hash = primitives.MustData32FromHex("0x2")
+	eb.PublishPendingTransaction(event.Transaction{ChainId: chainId, Hash: hash})
+	for i := 0; i < 2; i++ {
+		msg := readMessage(t, conn)
+		var got primitives.Data32
+		require.NoError(t, jsoniter.Unmarshal(msg.Params.Result, &got))
+		assert.Equal(t, hash, got)
+	}
+
+	require.NoError(t, conn.Close())
+	assert.Eventually(t, func() bool { return pendingCount() == 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
+// closingBroker lets the test close the newHeads subscriptions as the EventBroker does for the slow consumers
+type closingBroker struct {
+	*events.EventBroker
+	err chan error
+}
+
+type closingSubscription struct {
+	broker.Subscription
+	err chan error
+}
+
+func (b *closingBroker) SubscribeNewHeads(ch chan event.Block) broker.Subscription {
+	return &closingSubscription{Subscription: b.EventBroker.SubscribeNewHeads(ch), err: b.err}
+}
+
+func (s *closingSubscription) Err() <-chan error {
+	return s.err
+}
+
+func TestEventsClosedByBroker(t *testing.T) {
+	eb := events.NewEventBroker()
+	go eb.Start()
+	b := &closingBroker{EventBroker: eb, err: make(chan error, 1)}
+	url := startEventsServerWithBroker(t, b)
+	newHeadsCount := subscriptionCount(eb, func(s events.Stats) int { return s.NewHeadsSubscriptions })
+	conn := dialEvents(t, url)
+	defer conn.Close()
+
+	var subId string
+	require.NoError(t, jsoniter.Unmarshal(call(t, conn, 1, "eth_subscribe", `["newHeads"]`).Result, &subId))
+	b.err <- &errs.SlowConsumerError{}
+
+	msg := readMessage(t, conn)
+	assert.Equal(t, "eth_subscription", msg.Method)
+	assert.Equal(t, subId, msg.Params.Subscription)
+	require.NotNil(t, msg.Params.Error)
+	assert.Equal(t, errs.SlowConsumer, msg.Params.Error.Code)
+	assert.Eventually(t, func() bool { return newHeadsCount() == 0 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "false", string(call(t, conn, 2, "eth_unsubscribe", fmt.Sprintf(`["%s"]`, subId)).Result))
+}
+
+// replayBlockHandler serves blocks 1 to latest, each one with a single log, reading the block failAt fails if set
+type replayBlockHandler struct {
+	db.BlockHandler
+	latest uint64
+	failAt uint64
+	// hold blocks the reads of the blocks until it is closed
+	hold chan struct{}
+}
+
+func (h *replayBlockHandler) BlockNumber(_ context.Context) (*primitives.HexUint, error) {
+	latest := primitives.HexUint(h.latest)
+	return &latest, nil
+}
+
+func (h *replayBlockHandler) GetBlockByNumber(_ context.Context, number common.BN64, _ bool) (*response.Block, error) {
+	if h.hold != nil {
+		<-h.hold
+	}
+	if uint64(number) > h.latest {
+		return nil, errors.New("not found")
+	}
+	if uint64(number) == h.failAt {
+		return nil, errors.New("read failed")
+	}
+	return &response.Block{Number: primitives.HexUint(number)}, nil
+}
+
+// GetLogsPage returns a single block per page
+func (h *replayBlockHandler) GetLogsPage(_ context.Context, filter *dbt.LogFilter) ([]*response.Log, *dbt.LogKey, error) {
+	height := filter.From.BlockHeight
+	if height == h.failAt {
+		return nil, nil, errors.New("read failed")
+	}
+	logs := []*response.Log{{BlockNumber: primitives.HexUint(height)}}
+	if height >= filter.To.BlockHeight {
+		return logs, nil, nil
+	}
+	return logs, &dbt.LogKey{BlockHeight: height + 1}, nil
+}
+
+func TestEventsReplay(t *testing.T) {
+	bh := &replayBlockHandler{latest: 5}
+	eb, url := startEventsServer(t, func(ev *Events) {
+		ev.WithBlockHandler(bh)
+		ev.MaxReplayBlocks = 3
+	})
+	conn := dialEvents(t, url)
+	defer conn.Close()
+
+	readHeights := func(subId string, n int) []primitives.HexUint {
+		var heights []primitives.HexUint
+		for i := 0; i < n; i++ {
+			msg := readMessage(t, conn)
+			require.Equal(t, subId, msg.Params.Subscription)
+			var v struct {
+				Number      *primitives.HexUint `json:"number"`
+				BlockNumber *primitives.HexUint `json:"blockNumber"`
+			}
+			require.NoError(t, jsoniter.Unmarshal(msg.Params.Result, &v))
+			if v.Number != nil {
+				heights = append(heights, *v.Number)
+			} else {
+				heights = append(heights, *v.BlockNumber)
+			}
+		}
+		return heights
+	}
+
+	var subId string
+	require.NoError(t, jsoniter.Unmarshal(call(t, conn, 1, "eth_subscribe", `["newHeads",{"fromBlock":"0x3"}]`).Result, &subId))
+	assert.Equal(t, []primitives.HexUint{3, 4, 5}, readHeights(subId, 3))
+	// late publication of a replayed block must not be sent twice
+	eb.PublishNewHeads(event.Block(&response.Block{Number: 5}))
+	eb.PublishNewHeads(event.Block(&response.Block{Number: 6}))
+	assert.Equal(t, []primitives.HexUint{6}, readHeights(subId, 1))
+	assert.Equal(t, "true", string(call(t, conn, 2, "eth_unsubscribe", fmt.Sprintf(`["%s"]`, subId)).Result))
+
+	require.NoError(t, jsoniter.Unmarshal(call(t, conn, 3, "eth_subscribe", `["logs",{"fromBlock":"0x4"}]`).Result, &subId))
+	assert.Equal(t, []primitives.HexUint{4, 5}, readHeights(subId, 2))
+	eb.PublishLogs(event.Logs{{BlockNumber: 5}})
+	eb.PublishLogs(event.Logs{{BlockNumber: 5, Removed: true}})
+	eb.PublishLogs(event.Logs{{BlockNumber: 5}})
+	assert.Equal(t, []primitives.HexUint{5, 5}, readHeights(subId, 2), "logs after a revert must be sent")
+	assert.Equal(t, "true", string(call(t, conn, 4, "eth_unsubscribe", fmt.Sprintf(`["%s"]`, subId)).Result))
+
+	msg := call(t, conn, 5, "eth_subscribe", `["newHeads",{"fromBlock":"0x1"}]`)
+	assert.Nil(t, msg.Result, "fromBlock too far behind")
+}
+
+func TestEventsReplayFailure(t *testing.T) {
+	bh := &replayBlockHandler{latest: 5, failAt: 4}
+	eb, url := startEventsServer(t, func(ev *Events) {
+		ev.WithBlockHandler(bh)
+	})
+	subsCount := subscriptionCount(eb, func(s events.Stats) int { return s.NewHeadsSubscriptions + s.LogsSubscriptions })
+	conn := dialEvents(t, url)
+	defer conn.Close()
+
+	for i, params := range []string{`["newHeads",{"fromBlock":"0x3"}]`, `["logs",{"fromBlock":"0x3"}]`} {
+		var subId string
+		require.NoError(t, jsoniter.Unmarshal(call(t, conn, 2*i+1, "eth_subscribe", params).Result, &subId))
+
+		msg := readMessage(t, conn)
+		require.Equal(t, subId, msg.Params.Subscription)
+		require.Nil(t, msg.Params.Error, "blocks before the failure must be replayed")
+
+		msg = readMessage(t, conn)
+		require.Equal(t, subId, msg.Params.Subscription)
+		require.NotNil(t, msg.Params.Error, "subscription must be closed when the replay fails")
+		assert.Equal(t, "read failed", msg.Params.Error.Message)
+
+		assert.Eventually(t, func() bool { return subsCount() == 0 }, time.Second, 10*time.Millisecond, "broker subscription must be released")
+		assert.Equal(t, "false", string(call(t, conn, 2*i+2, "eth_unsubscribe", fmt.Sprintf(`["%s"]`, subId)).Result))
+	}
+}
+
+func TestEventsReplayQueueOverflow(t *testing.T) {
+	bh := &replayBlockHandler{latest: 5, hold: make(chan struct{})}
+	eb, url := startEventsServer(t, func(ev *Events) {
+		ev.WithBlockHandler(bh)
+		ev.MaxReplayQueue = 1
+	})
+	subsCount := subscriptionCount(eb, func(s events.Stats) int { return s.NewHeadsSubscriptions })
+	conn := dialEvents(t, url)
+	defer conn.Close()
+
+	var subId string
+	require.NoError(t, jsoniter.Unmarshal(call(t, conn, 1, "eth_subscribe", `["newHeads",{"fromBlock":"0x3"}]`).Result, &subId))
+	// the replay is held while more heads than the queue can take are published
+	eb.PublishNewHeads(event.Block(&response.Block{Number: 6}))
+	eb.PublishNewHeads(event.Block(&response.Block{Number: 7}))
+	time.Sleep(100 * time.Millisecond)
+	close(bh.hold)
+
+	for {
+		msg := readMessage(t, conn)
+		require.Equal(t, subId, msg.Params.Subscription)
+		if msg.Params.Error != nil {
+			assert.Equal(t, errs.SlowConsumer, msg.Params.Error.Code, "subscription must be closed as a slow consumer")
+			break
+		}
+	}
+	assert.Eventually(t, func() bool { return subsCount() == 0 }, time.Second, 10*time.Millisecond, "broker subscription must be released")
+}
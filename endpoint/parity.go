@@ -2,7 +2,6 @@ package endpoint
 
 import (
 	"github.com/aurora-is-near/relayer2-base/types/common"
-	"github.com/aurora-is-near/relayer2-base/utils"
 
 	"golang.org/x/net/context"
 )
@@ -15,10 +14,14 @@ func NewParity(endpoint *Endpoint) *Parity {
 	return &Parity{endpoint}
 }
 
-// PendingTransactions returns a list of txs currently in the queue.
-// As of now method always returns empty array since the relayer has no txs queue support.
+// PendingTransactions returns the hashes of the txs submitted by the relayer which are not included in a block yet, up
+// to the given limit. Returns empty array if the relayer doesn't track its submitted txs (see Endpoint.TxPool).
 //
 //	If API is disabled, returns errors code '-32601' with message 'the method does not exist/is not available'.
-func (p *Parity) PendingTransactions(_ context.Context, _ *common.Uint64, _ *interface{}) (*[]string, error) {
-	return utils.Constants.EmptyArray(), nil
+func (p *Parity) PendingTransactions(ctx context.Context, limit *common.Uint64, _ *interface{}) (*[]string, error) {
+	l := 0
+	if limit != nil {
+		l = int(limit.Uint64())
+	}
+	return p.pendingTransactions(ctx, l), nil
 }
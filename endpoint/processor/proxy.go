@@ -0,0 +1,137 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/endpoint"
+	"github.com/aurora-is-near/relayer2-base/rpc"
+	errs "github.com/aurora-is-near/relayer2-base/types/errors"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/atomic"
+)
+
+const proxyRequestTemplate = `{"jsonrpc":"2.0","id":%d,"method":"%s","params":%s}`
+
+type proxyResponse struct {
+	Result jsoniter.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int                 `json:"code"`
+		Message string              `json:"message"`
+		Data    jsoniter.RawMessage `json:"data"`
+	} `json:"error"`
+}
+
+// Proxy forwards the calls of the endpoints listed in the `proxyEndpoints` configuration to the upstream JSON-RPC
+// server and short-circuits the processing with the upstream response.
+type Proxy struct {
+	client *fasthttp.Client
+	reqId  atomic.Uint64
+}
+
+// NewProxy creates the proxy processor of the endpoint with the given config, the connections to the upstream are
+// limited by its ProxyMaxConns
+func NewProxy(config *endpoint.Config) endpoint.Processor {
+	return &Proxy{
+		client: &fasthttp.Client{
+			MaxConnsPerHost:          config.ProxyMaxConns,
+			NoDefaultUserAgentHeader: true,
+		},
+	}
+}
+
+func (p *Proxy) Pre(ctx context.Context, name string, endpoint *endpoint.Endpoint, response *any, args ...any) (context.Context, bool, error) {
+	if !endpoint.Config.ProxyEndpoints[name] {
+		return ctx, false, nil
+	}
+
+	params, err := proxyParams(ctx, args)
+	if err != nil {
+		return ctx, true, &errs.InvalidParamsError{Message: err.Error()}
+	}
+
+	result, err := p.forward(ctx, endpoint.Config.ProxyUrl, name, params, endpoint.Config.ProxyTimeoutFor(name))
+	if err != nil {
+		return ctx, true, err
+	}
+	if string(result) != "null" {
+		*response = result
+	}
+	return ctx, true, nil
+}
+
+func (p *Proxy) Post(ctx context.Context, _ string, _ *any, _ *error) context.Context {
+	return ctx
+}
+
+// forward sends the request to the upstream and returns the raw result, upstream JSON-RPC errors are returned with
+// their original code and message
+func (p *Proxy) forward(ctx context.Context, url string, name string, params []byte, timeout time.Duration) (jsoniter.RawMessage, error) {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(url)
+	req.Header.SetContentType(rpc.DefaultContentType)
+	req.Header.SetMethod(http.MethodPost)
+	req.SetBody([]byte(fmt.Sprintf(proxyRequestTemplate, p.reqId.Inc(), name, params)))
+
+	if err := p.client.DoTimeout(req, resp, timeout); err != nil {
+		return nil, &errs.GenericError{Err: fmt.Errorf("failed to proxy %s: %w", name, err)}
+	}
+
+	var pr proxyResponse
+	if err := jsoniter.Unmarshal(resp.Body(), &pr); err != nil {
+		if resp.StatusCode() != fasthttp.StatusOK {
+			return nil, &errs.GenericError{Err: fmt.Errorf("failed to proxy %s: upstream responded with status %d", name, resp.StatusCode())}
+		}
+		return nil, &errs.GenericError{Err: fmt.Errorf("failed to proxy %s: %w", name, err)}
+	}
+
+	if pr.Error != nil {
+		var data string
+		if len(pr.Error.Data) > 0 && jsoniter.Unmarshal(pr.Error.Data, &data) == nil && data != "" {
+			return nil, &errs.TxsRevertError{Code: pr.Error.Code, Message: pr.Error.Message, Data: data}
+		}
+		return nil, &errs.UpstreamError{Code: pr.Error.Code, Message: pr.Error.Message}
+	}
+	if pr.Result == nil {
+		return jsoniter.RawMessage("null"), nil
+	}
+	return pr.Result, nil
+}
+
+// proxyParams returns the params as they were received if the request came through the rpc server, otherwise the
+// parsed args are encoded back with the trailing nil (optional) args dropped
+func proxyParams(ctx context.Context, args []any) ([]byte, error) {
+	if params, ok := rpc.RawParamsFromContext(ctx); ok && len(params) > 0 {
+		return params, nil
+	}
+
+	n := len(args)
+	for n > 0 && isNil(args[n-1]) {
+		n--
+	}
+	return jsoniter.Marshal(args[:n])
+}
+
+func isNil(arg any) bool {
+	if arg == nil {
+		return true
+	}
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
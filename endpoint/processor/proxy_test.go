@@ -0,0 +1,152 @@
+package processor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/endpoint"
+	"github.com/aurora-is-near/relayer2-base/rpc"
+	"github.com/aurora-is-near/relayer2-base/types/common"
+	errs "github.com/aurora-is-near/relayer2-base/types/errors"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type upstreamRequest struct {
+	Method string              `json:"method"`
+	Params jsoniter.RawMessage `json:"params"`
+}
+
+func newUpstream(t *testing.T, respond func(req upstreamRequest) string) (*httptest.Server, chan upstreamRequest) {
+	received := make(chan upstreamRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var req upstreamRequest
+		require.NoError(t, jsoniter.Unmarshal(body, &req))
+		received <- req
+		w.Header().Set("Content-Type", rpc.DefaultContentType)
+		_, _ = w.Write([]byte(respond(req)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, received
+}
+
+func newProxyEndpoint(url string, proxied ...string) *endpoint.Endpoint {
+	config := &endpoint.Config{
+		ProxyUrl:              url,
+		ProxyEndpoints:        map[string]bool{},
+		ProxyTimeout:          time.Second,
+		ProxyEndpointTimeouts: map[string]time.Duration{},
+		ProxyMaxConns:         4,
+	}
+	ep := &endpoint.Endpoint{
+		Config:     config,
+		Processors: []endpoint.Processor{NewProxy(config)},
+	}
+	for _, p := range proxied {
+		ep.Config.ProxyEndpoints[p] = true
+	}
+	return ep
+}
+
+func TestProxyForwardsConfiguredEndpoints(t *testing.T) {
+	srv, received := newUpstream(t, func(req upstreamRequest) string {
+		return `{"jsonrpc":"2.0","id":1,"result":"0x5208"}`
+	})
+	ep := newProxyEndpoint(srv.URL, "eth_estimateGas")
+
+	params := `[{"from":"0x0000000000000000000000000000000000000001"},"latest"]`
+	ctx := rpc.PutRawParams(context.Background(), []byte(params))
+	res, err := endpoint.Process(ctx, "eth_estimateGas", ep, func(ctx context.Context) (*common.Uint256, error) {
+		t.Fatal("handler must not be called for a proxied endpoint")
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	assert.Equal(t, uint64(21000), res.Uint64())
+
+	req := <-received
+	assert.Equal(t, "eth_estimateGas", req.Method)
+	assert.JSONEq(t, params, string(req.Params))
+}
+
+func TestProxyEncodesArgsWithoutRawParams(t *testing.T) {
+	srv, received := newUpstream(t, func(req upstreamRequest) string {
+		return `{"jsonrpc":"2.0","id":1,"result":null}`
+	})
+	ep := newProxyEndpoint(srv.URL, "debug_traceTransaction")
+
+	var tracer *string
+	res, err := endpoint.Process(context.Background(), "debug_traceTransaction", ep, func(ctx context.Context) (*string, error) {
+		t.Fatal("handler must not be called for a proxied endpoint")
+		return nil, nil
+	}, "0x01", tracer)
+	require.NoError(t, err)
+	assert.Nil(t, res)
+
+	req := <-received
+	assert.JSONEq(t, `["0x01"]`, string(req.Params))
+}
+
+func TestProxySkipsOtherEndpoints(t *testing.T) {
+	srv, received := newUpstream(t, func(req upstreamRequest) string {
+		return `{"jsonrpc":"2.0","id":1,"result":"0x1"}`
+	})
+	ep := newProxyEndpoint(srv.URL, "eth_estimateGas")
+
+	local := "local"
+	res, err := endpoint.Process(context.Background(), "eth_chainId", ep, func(ctx context.Context) (*string, error) {
+		return &local, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "local", *res)
+	assert.Len(t, received, 0)
+}
+
+func TestProxyPassesUpstreamErrors(t *testing.T) {
+	srv, _ := newUpstream(t, func(req upstreamRequest) string {
+		if req.Method == "eth_call" {
+			return `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted","data":"0x08c379a0"}}`
+		}
+		return `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the method does not exist"}}`
+	})
+	ep := newProxyEndpoint(srv.URL, "eth_call", "eth_estimateGas")
+	handler := func(ctx context.Context) (*string, error) { return nil, nil }
+
+	_, err := endpoint.Process(context.Background(), "eth_call", ep, handler)
+	require.Error(t, err)
+	assert.Equal(t, 3, err.(errs.Error).ErrorCode())
+	assert.Equal(t, "execution reverted", err.Error())
+	assert.Equal(t, "0x08c379a0", err.(errs.DataError).ErrorData())
+
+	_, err = endpoint.Process(context.Background(), "eth_estimateGas", ep, handler)
+	require.Error(t, err)
+	assert.Equal(t, errs.MethodNotFound, err.(errs.Error).ErrorCode())
+	_, isDataErr := err.(errs.DataError)
+	assert.False(t, isDataErr)
+}
+
+func TestProxyEndpointTimeouts(t *testing.T) {
+	srv, _ := newUpstream(t, func(req upstreamRequest) string {
+		time.Sleep(200 * time.Millisecond)
+		return `{"jsonrpc":"2.0","id":1,"result":"0x1"}`
+	})
+	ep := newProxyEndpoint(srv.URL, "eth_call", "debug_traceTransaction")
+	ep.Config.ProxyTimeout = 50 * time.Millisecond
+	ep.Config.ProxyEndpointTimeouts["debug_tracetransaction"] = 2 * time.Second
+	handler := func(ctx context.Context) (*string, error) { return nil, nil }
+
+	_, err := endpoint.Process(context.Background(), "eth_call", ep, handler)
+	require.Error(t, err)
+	assert.Equal(t, errs.Generic, err.(errs.Error).ErrorCode())
+
+	res, err := endpoint.Process(context.Background(), "debug_traceTransaction", ep, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "0x1", *res)
+}
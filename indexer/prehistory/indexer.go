@@ -12,6 +12,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/aurora-is-near/relayer2-base/db"
+	"github.com/aurora-is-near/relayer2-base/indexer/progress"
 	"github.com/aurora-is-near/relayer2-base/log"
 	"github.com/aurora-is-near/relayer2-base/types/indexer"
 	"github.com/aurora-is-near/relayer2-base/types/primitives"
@@ -19,7 +20,8 @@ import (
 )
 
 const (
-	blankHash = "0x0000000000000000000000000000000000000000000000000000000000000000"
+	blankHash    = "0x0000000000000000000000000000000000000000000000000000000000000000"
+	progressName = "prehistoryIndexer"
 )
 
 type Indexer struct {
@@ -101,6 +103,14 @@ func (i *Indexer) Close() {
 
 // Start starts the prehistory indexing as a goroutine based on the config file settings
 func (i *Indexer) index() {
+	// the blocks are indexed up to To exclusive, an empty range has nothing to report
+	target := i.Config.From
+	if i.Config.To > i.Config.From {
+		target = i.Config.To - 1
+	}
+	tracker := progress.Track(progressName, i.Config.From, target)
+	defer tracker.Done()
+
 	var err error
 	i.reader.dbPool, err = pgxpool.New(context.Background(), i.Config.ArchiveURL)
 	if err != nil {
@@ -206,6 +216,7 @@ loop:
 			if err != nil {
 				i.logger.Error().Msgf("failed to insert block [%d], with err: %v\n", nBlock.Height, err)
 			}
+			tracker.Update(cBlock)
 			parentHash = blockHash
 		}
 		select {
@@ -0,0 +1,101 @@
+package progress
+
+import (
+	"sync"
+)
+
+// Status is the progress of an indexer in block heights
+type Status struct {
+	Start   uint64
+	Current uint64
+	Target  uint64
+}
+
+// Lagging returns true if the indexer has not reached its target yet
+func (s Status) Lagging() bool {
+	return s.Current < s.Target
+}
+
+// Tracker records the progress of a single indexer in the global registry, see Track
+type Tracker struct {
+	name   string
+	mu     sync.RWMutex
+	status Status
+}
+
+var (
+	mu       sync.RWMutex
+	trackers = map[string]*Tracker{}
+)
+
+// Track registers an indexer with the given start and target heights and returns its tracker. If an indexer with the
+// same name is already registered, it is replaced.
+func Track(name string, start, target uint64) *Tracker {
+	t := &Tracker{
+		name:   name,
+		status: Status{Start: start, Current: start, Target: target},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	trackers[name] = t
+	return t
+}
+
+// Update sets the height the indexer has reached
+func (t *Tracker) Update(current uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.Current = current
+}
+
+// SetTarget sets the height the indexer is heading to
+func (t *Tracker) SetTarget(target uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.Target = target
+}
+
+// Status returns the current progress of the indexer
+func (t *Tracker) Status() Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.status
+}
+
+// Done removes the indexer from the registry, it is not reported as syncing anymore
+func (t *Tracker) Done() {
+	mu.Lock()
+	defer mu.Unlock()
+	if trackers[t.name] == t {
+		delete(trackers, t.name)
+	}
+}
+
+// Get returns the progress of the indexer registered with the given name
+func Get(name string) (Status, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	t, ok := trackers[name]
+	if !ok {
+		return Status{}, false
+	}
+	return t.Status(), true
+}
+
+// Syncing returns the progress of the registered indexer which is the furthest behind its target, or nil if none of
+// them is lagging
+func Syncing() *Status {
+	mu.RLock()
+	defer mu.RUnlock()
+	var lagging *Status
+	for _, t := range trackers {
+		s := t.Status()
+		if !s.Lagging() {
+			continue
+		}
+		if lagging == nil || s.Target-s.Current > lagging.Target-lagging.Current {
+			lagging = &s
+		}
+	}
+	return lagging
+}
@@ -0,0 +1,35 @@
+package progress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncing(t *testing.T) {
+	assert.Nil(t, Syncing())
+
+	a := Track("a", 100, 200)
+	b := Track("b", 0, 1000)
+	defer a.Done()
+	defer b.Done()
+
+	s := Syncing()
+	require.NotNil(t, s)
+	assert.Equal(t, Status{Start: 0, Current: 0, Target: 1000}, *s, "the furthest behind must be reported")
+
+	b.Update(990)
+	a.Update(150)
+	assert.Equal(t, Status{Start: 100, Current: 150, Target: 200}, *Syncing())
+
+	a.Update(200)
+	b.Done()
+	assert.Nil(t, Syncing(), "indexers reaching their target must not be reported")
+
+	a.SetTarget(300)
+	assert.Equal(t, Status{Start: 100, Current: 200, Target: 300}, *Syncing())
+	st, ok := Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(300), st.Target)
+}
@@ -10,10 +10,13 @@ import (
 
 	"github.com/aurora-is-near/relayer2-base/db"
 	"github.com/aurora-is-near/relayer2-base/db/codec"
+	"github.com/aurora-is-near/relayer2-base/indexer/progress"
 	"github.com/aurora-is-near/relayer2-base/log"
 	"github.com/aurora-is-near/relayer2-base/types/indexer"
 )
 
+const progressName = "tarIndexer"
+
 type Indexer struct {
 	Config *Config
 	dbh    db.Handler
@@ -53,12 +56,19 @@ func (i *Indexer) Start() {
 	}
 	defer i.reader.CloseReader()
 
+	start, target, err := i.heights()
+	if err != nil {
+		i.logger.Warn().Err(err).Msg("failed to read the block heights of the backup, indexing progress isn't reported")
+	}
+	tracker := progress.Track(progressName, start, target)
+	defer tracker.Done()
+
 	if err := i.reader.SeekReader(i.Config.From); err != nil {
 		i.logger.Fatal().Err(err).Msg("failed to position file reader")
 	}
 
 	for {
-		seq, data, err := i.reader.ReadNext()
+		seq, block, err := i.readBlock()
 		if err != nil {
 			if err.Error() == "not found" {
 				break
@@ -66,16 +76,6 @@ func (i *Indexer) Start() {
 			i.logger.Fatal().Err(err).Msgf("reader failed to read")
 		}
 
-		var m messagebackup.MessageBackup
-		if err = m.UnmarshalVT(data); err != nil {
-			i.logger.Fatal().Err(err).Msgf("failed to decode backup [%d]", seq)
-		}
-
-		block, err := DecodeAugmentedCBOR[indexer.Block](m.Data, i.mode)
-		if err != nil {
-			i.logger.Fatal().Err(err).Msgf("failed decode block from backup seq [%d]", m.Sequence)
-		}
-
 		if seq%1000 == 0 {
 			i.logger.Info().Msgf("inserting backup block: [%d] - seq: [%d]", block.Height, seq)
 		}
@@ -84,6 +84,7 @@ func (i *Indexer) Start() {
 		if err != nil {
 			i.logger.Fatal().Err(err).Msgf("failed to insert block [%d]", block.Height)
 		}
+		tracker.Update(block.Height)
 		if i.Config.To != 0 && i.Config.To == seq {
 			break
 		}
@@ -91,6 +92,56 @@ func (i *Indexer) Start() {
 	i.logger.Info().Msgf("backup indexer finished")
 }
 
+// readBlock reads and decodes the next block from the backup files, returns it with its sequence
+func (i *Indexer) readBlock() (uint64, *indexer.Block, error) {
+	seq, data, err := i.reader.ReadNext()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var m messagebackup.MessageBackup
+	if err = m.UnmarshalVT(data); err != nil {
+		return seq, nil, fmt.Errorf("failed to decode backup [%d]: %w", seq, err)
+	}
+
+	block, err := DecodeAugmentedCBOR[indexer.Block](m.Data, i.mode)
+	if err != nil {
+		return seq, nil, fmt.Errorf("failed decode block from backup seq [%d]: %w", m.Sequence, err)
+	}
+	return seq, block, nil
+}
+
+// heights returns the heights of the first and the last blocks to index, the reader must be positioned again before
+// reading the blocks
+func (i *Indexer) heights() (uint64, uint64, error) {
+	var heights [2]uint64
+	for n, seq := range []uint64{i.Config.From, i.lastSeq()} {
+		if err := i.reader.SeekReader(seq); err != nil {
+			return 0, 0, err
+		}
+		_, block, err := i.readBlock()
+		if err != nil {
+			return 0, 0, err
+		}
+		heights[n] = block.Height
+	}
+	return heights[0], heights[1], nil
+}
+
+// lastSeq returns the sequence the indexer stops at, either the configured one or the last one in the backup files
+func (i *Indexer) lastSeq() uint64 {
+	if i.Config.To != 0 {
+		return i.Config.To
+	}
+	var last uint64
+	for _, c := range i.reader.GetChunkRanges() {
+		if c.R > last {
+			last = c.R
+		}
+	}
+	return last
+}
+
 func (i *Indexer) Close() {
 }
 
@@ -6,6 +6,7 @@ type _probe struct {
 	config  *Config
 	server  server
 	metrics map[string]Metric
+	vecs    map[string]collector
 }
 
 var probe *_probe
@@ -22,6 +23,7 @@ func Start() {
 			config:  config,
 			server:  newServer(config.ServerConfig),
 			metrics: make(map[string]Metric),
+			vecs:    make(map[string]collector),
 		}
 		probe.server.start()
 	}
@@ -63,25 +65,7 @@ func Set(metricConfig MetricConfig) (Metric, bool) {
 		}
 
 		// check config and overwrite given metricConfig with config if id matches
-		for _, mc := range *probe.config.MetricConfigs {
-			if mc.Id == metricConfig.Id {
-				if mc.Name != "" {
-					metricConfig.Name = mc.Name
-				}
-				if mc.Help != "" {
-					metricConfig.Help = mc.Help
-				}
-				if mc.LabelValues != nil && mc.LabelNames != nil {
-					if len(mc.LabelValues) > 0 && len(mc.LabelNames) > 0 {
-						metricConfig.LabelNames = mc.LabelNames
-						metricConfig.LabelValues = mc.LabelValues
-					}
-				}
-				if mc.Buckets != nil {
-					metricConfig.Buckets = mc.Buckets
-				}
-			}
-		}
+		metricConfig = probe.configured(metricConfig)
 
 		switch metricConfig.Type {
 		case "Gauge":
@@ -123,6 +107,30 @@ func Set(metricConfig MetricConfig) (Metric, bool) {
 	return nil, false
 }
 
+// configured returns the given metricConfig overwritten by the config file values of the metric with the same id
+func (p *_probe) configured(metricConfig MetricConfig) MetricConfig {
+	for _, mc := range *p.config.MetricConfigs {
+		if mc.Id == metricConfig.Id {
+			if mc.Name != "" {
+				metricConfig.Name = mc.Name
+			}
+			if mc.Help != "" {
+				metricConfig.Help = mc.Help
+			}
+			if mc.LabelValues != nil && mc.LabelNames != nil {
+				if len(mc.LabelValues) > 0 && len(mc.LabelNames) > 0 {
+					metricConfig.LabelNames = mc.LabelNames
+					metricConfig.LabelValues = mc.LabelValues
+				}
+			}
+			if mc.Buckets != nil {
+				metricConfig.Buckets = mc.Buckets
+			}
+		}
+	}
+	return metricConfig
+}
+
 // Enabled returns true if probe is started
 func Enabled() bool {
 	return probe != nil
@@ -0,0 +1,66 @@
+package probe
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// SetCounterVec creates and registers a counter vector with given metricConfig if probe is enabled and started, label
+// values are given by the caller on each observation. Name, help and buckets are overwritten by the config file as in
+// Set, label names are not since the caller relies on them.
+//   - On success, returns (vector, true)
+//   - If probe is disabled or not started, returns (nil, false)
+//   - If there is a vector created with same id previously, returns the previously created vector.
+func SetCounterVec(metricConfig MetricConfig) (*prometheus.CounterVec, bool) {
+	return setVec(metricConfig, func(mc MetricConfig, sc *ServerConfig) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: sc.Namespace,
+			Subsystem: sc.Subsystem,
+			Name:      mc.Name,
+			Help:      mc.Help,
+		}, mc.LabelNames)
+	})
+}
+
+// SetGaugeVec creates and registers a gauge vector, see SetCounterVec
+func SetGaugeVec(metricConfig MetricConfig) (*prometheus.GaugeVec, bool) {
+	return setVec(metricConfig, func(mc MetricConfig, sc *ServerConfig) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: sc.Namespace,
+			Subsystem: sc.Subsystem,
+			Name:      mc.Name,
+			Help:      mc.Help,
+		}, mc.LabelNames)
+	})
+}
+
+// SetHistogramVec creates and registers a histogram vector, see SetCounterVec. Prometheus default buckets are used if
+// metricConfig has no buckets.
+func SetHistogramVec(metricConfig MetricConfig) (*prometheus.HistogramVec, bool) {
+	return setVec(metricConfig, func(mc MetricConfig, sc *ServerConfig) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: sc.Namespace,
+			Subsystem: sc.Subsystem,
+			Name:      mc.Name,
+			Help:      mc.Help,
+			Buckets:   mc.Buckets,
+		}, mc.LabelNames)
+	})
+}
+
+func setVec[T collector](metricConfig MetricConfig, create func(MetricConfig, *ServerConfig) T) (T, bool) {
+	var vec T
+	if !Enabled() {
+		return vec, false
+	}
+	if c, ok := probe.vecs[metricConfig.Id]; ok {
+		vec, ok = c.(T)
+		return vec, ok
+	}
+
+	labelNames := metricConfig.LabelNames
+	metricConfig = probe.configured(metricConfig)
+	metricConfig.LabelNames = labelNames
+
+	vec = create(metricConfig, probe.server.config)
+	probe.server.register(vec)
+	probe.vecs[metricConfig.Id] = vec
+	return vec, true
+}
@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"context"
 	"sync"
 
 	"github.com/buger/jsonparser"
@@ -20,6 +21,7 @@ type RpcContext struct {
 	parsedBody     *types.RPCRequestBody
 	method         string
 	parseFailed    bool
+	errorCode      int64
 	response       []byte
 	batchChildren  []*RpcContext
 }
@@ -36,11 +38,48 @@ func (r *RpcServer) newRpcContext(wsCtx *WebSocketContext, body []byte, dataType
 
 type WebSocketContext struct {
 	ws               *websocket.Conn
+	metrics          *Metrics
 	output           chan []byte
+	outputMtx        sync.Mutex
 	subscriptions    map[ID]*Subscription
 	subscriptionsMtx sync.Mutex
-	outputWg         sync.WaitGroup
-	closed           atomic.Bool
+	// inactiveNotifiers are created by the request being served, see respond
+	inactiveNotifiers []*Notifier
+	outputWg          sync.WaitGroup
+	closed            atomic.Bool
+}
+
+// send queues the data to be written to the connection, the data is dropped if the connection is closed
+func (ws *WebSocketContext) send(data []byte) {
+	ws.outputMtx.Lock()
+	defer ws.outputMtx.Unlock()
+	if !ws.closed.Load() {
+		ws.output <- data
+	}
+}
+
+// respond queues the response of a request and then activates the notifiers created while serving it, so that the
+// client receives the subscription ids before their first notifications
+func (ws *WebSocketContext) respond(resp []byte) {
+	if resp != nil {
+		ws.send(resp)
+	}
+	ws.subscriptionsMtx.Lock()
+	notifiers := ws.inactiveNotifiers
+	ws.inactiveNotifiers = nil
+	ws.subscriptionsMtx.Unlock()
+	for _, n := range notifiers {
+		n.activate()
+	}
+}
+
+// close marks the connection as closed and closes the output channel so that the output writer returns. Senders
+// blocked on a full output channel are released by the writer, which keeps draining the channel until it is closed.
+func (ws *WebSocketContext) close() {
+	ws.closed.Store(true)
+	ws.outputMtx.Lock()
+	defer ws.outputMtx.Unlock()
+	close(ws.output)
 }
 
 // hasParseError returns if there is parse error or not
@@ -76,6 +115,7 @@ func (ctx *RpcContext) setNotificationResponse() *RpcContext {
 // setError sets response as error using the error code and message
 func (ctx *RpcContext) setError(code int64, message string) *RpcContext {
 	ctx.parseFailed = true
+	ctx.errorCode = code
 	ctx.response = createErrorResponse(ctx.getRpcIdRepr(), code, message)
 	return ctx
 }
@@ -83,6 +123,7 @@ func (ctx *RpcContext) setError(code int64, message string) *RpcContext {
 // SetErrorObject sets response as error using the provided error object
 func (ctx *RpcContext) SetErrorObject(e errs.Error) *RpcContext {
 	ctx.parseFailed = true
+	ctx.errorCode = int64(e.ErrorCode())
 	de, ok := e.(errs.DataError)
 	if ok {
 		ctx.response = createDataErrorResponse(ctx.getRpcIdRepr(), int64(e.ErrorCode()), e.Error(), de.ErrorData())
@@ -115,7 +156,26 @@ func (ctx *RpcContext) GetMethod() string {
 	return ctx.method
 }
 
+// GetErrorCode returns the JSON-RPC error code of the response, 0 if the response is not an error
+func (ctx *RpcContext) GetErrorCode() int64 {
+	return ctx.errorCode
+}
+
 // GetBody returns the body as a byte slice
 func (ctx *RpcContext) GetBody() []byte {
 	return ctx.body
 }
+
+type rawParamsKey struct{}
+
+// PutRawParams is a helper function to put the raw JSON params of the served request in the context so that
+// the handlers forwarding the request elsewhere can pass the params on exactly as they were received
+func PutRawParams(ctx context.Context, params []byte) context.Context {
+	return context.WithValue(ctx, rawParamsKey{}, params)
+}
+
+// RawParamsFromContext returns the raw JSON params stored in ctx, if any.
+func RawParamsFromContext(ctx context.Context) ([]byte, bool) {
+	params, ok := ctx.Value(rawParamsKey{}).([]byte)
+	return params, ok
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/valyala/fasthttp"
 	"github.com/valyala/fasthttp/fasthttpadaptor"
 	"github.com/valyala/fasthttp/reuseport"
+	"go.uber.org/atomic"
 )
 
 const (
@@ -27,6 +28,7 @@ const (
 	wsReadBuffer       = 1024
 	wsWriteBuffer      = 1024
 	wsMessageSizeLimit = 15 * 1024 * 1024
+	wsCloseTimeout     = time.Second
 )
 
 // https://www.jsonrpc.org/historical/json-rpc-over-http.html#id13
@@ -37,7 +39,12 @@ type HttpServer struct {
 	Config     HttpConfig
 	resolver   Resolver
 	listener   net.Listener
+	server     *fasthttp.Server
 	wsUpgrader *websocket.FastHTTPUpgrader
+	wsConnsMtx sync.Mutex
+	wsConns    map[*websocket.Conn]struct{}
+	wsConnsWg  sync.WaitGroup
+	stopping   atomic.Bool
 }
 
 // HttpConfig holds both http and websocket configuration elements
@@ -95,9 +102,12 @@ func (h *HttpServer) Run(ctx context.Context, resolver Resolver) error {
 	}
 	reqHandler = fasthttp.TimeoutHandler(reqHandler, h.Config.HttpTimeout*time.Second, "request timeout")
 
+	h.server = &fasthttp.Server{Handler: reqHandler}
+	h.wsConns = map[*websocket.Conn]struct{}{}
 	go func() {
-		if err := fasthttp.Serve(h.listener, reqHandler); err != nil {
-			h.Logger.Fatal().Err(err).Msg("error while serving http")
+		// Serve returns nil once the server is shut down
+		if err := h.server.Serve(h.listener); err != nil {
+			h.Logger.Error().Err(err).Msg("error while serving http")
 		}
 	}()
 	return nil
@@ -144,9 +154,14 @@ func (h *HttpServer) mainHandler(ctx *fasthttp.RequestCtx) {
 func (h *HttpServer) fastWsHandler(ctx *fasthttp.RequestCtx) {
 	err := upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
 		defer conn.Close()
+		if !h.trackWs(conn) {
+			return
+		}
+		defer h.untrackWs(conn)
 		wsCtx := &WebSocketContext{ws: conn, subscriptions: make(map[ID]*Subscription), subscriptionsMtx: sync.Mutex{}}
 		wsCtx.output = make(chan []byte, 100)
 		wsCtx.closed.Store(false)
+		openWsConn(h.resolver, wsCtx)
 
 		wsCtx.outputWg.Add(1)
 		go h.handleWebSocketOutput(wsCtx)
@@ -160,18 +175,21 @@ func (h *HttpServer) fastWsHandler(ctx *fasthttp.RequestCtx) {
 				h.Logger.Warn().Msgf("websocket: got message with unknown type #%v", messageType)
 				continue
 			}
-			// get the clientIp and add it to context so rpcserver can use it when needed
+			// get the clientIp and add it to context so rpcserver can use it when needed, the fasthttp context is canceled
+			// as soon as the server starts shutting down so the in-flight requests would not be drained
 			clientIp := ctx.RemoteIP()
-			cCtx := utils.PutClientIpKey(ctx, clientIp)
-			resp := h.resolver.ResolveWs(&cCtx, wsCtx, message)
-			if resp != nil {
-				wsCtx.output <- resp
-			}
+			cCtx := utils.PutClientIpKey(utils.WithoutCancel(ctx), clientIp)
+			wsCtx.respond(h.resolver.ResolveWs(&cCtx, wsCtx, message))
 		}
 
-		wsCtx.closed.Store(true)
-		h.resolver.CloseWsConn(wsCtx)
+		wsCtx.close()
 		wsCtx.outputWg.Wait()
+		h.resolver.CloseWsConn(wsCtx)
+		if h.stopping.Load() {
+			// the responses of the in-flight requests are written, the client can be told to go away
+			msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down")
+			_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsCloseTimeout))
+		}
 	})
 	if err != nil {
 		h.Logger.Error().Err(err).Msg("error upgrading to websocket")
@@ -204,9 +222,10 @@ func (h *HttpServer) fastHTTPHandler(ctx *fasthttp.RequestCtx, r *http.Request)
 	ctx.SetContentType(DefaultContentType)
 	ctx.SetStatusCode(http.StatusOK)
 
-	// get the clientIp and add it to context so rpcserver can use it when needed
+	// get the clientIp and add it to context so rpcserver can use it when needed, the fasthttp context is canceled as
+	// soon as the server starts shutting down so the in-flight requests would not be drained
 	clientIp := ctx.RemoteIP()
-	cCtx := utils.PutClientIpKey(ctx, clientIp)
+	cCtx := utils.PutClientIpKey(utils.WithoutCancel(ctx), clientIp)
 	resp := h.resolver.ResolveHttp(&cCtx, ctx.Request.Body())
 	ctx.SetBody(resp)
 }
@@ -226,16 +245,75 @@ func (h *HttpServer) newCorsHandler(headers []string) *cors.CorsHandler {
 	return c
 }
 
-// Stop stops http server
+// trackWs registers the websocket connection to be closed by Shutdown, returns false if the server is shutting down
+func (h *HttpServer) trackWs(conn *websocket.Conn) bool {
+	h.wsConnsMtx.Lock()
+	defer h.wsConnsMtx.Unlock()
+	if h.stopping.Load() {
+		return false
+	}
+	h.wsConns[conn] = struct{}{}
+	h.wsConnsWg.Add(1)
+	return true
+}
+
+func (h *HttpServer) untrackWs(conn *websocket.Conn) {
+	h.wsConnsMtx.Lock()
+	defer h.wsConnsMtx.Unlock()
+	delete(h.wsConns, conn)
+	h.wsConnsWg.Done()
+}
+
+// Stop stops http server without waiting for the in-flight requests
 func (h *HttpServer) Stop() error {
-	h.Logger.Info().Msg("stopping http listener...")
-	if err := h.listener.Close(); err != nil {
-		h.Logger.Error().Msgf("error while closing listener: %v", err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := h.Shutdown(ctx); err != nil && !errors.Is(err, context.Canceled) {
 		return err
 	}
 	return nil
 }
 
+// Shutdown closes the listener and waits until the in-flight http requests are served and the websocket connections
+// are closed, or ctx is done. The websocket connections stop reading requests, and get a close frame once the responses
+// of their in-flight requests are written. The connections still open when ctx is done are closed.
+func (h *HttpServer) Shutdown(ctx context.Context) error {
+	h.Logger.Info().Msg("stopping http listener...")
+	if h.server == nil {
+		return nil
+	}
+	h.wsConnsMtx.Lock()
+	h.stopping.Store(true)
+	for conn := range h.wsConns {
+		// the blocked read returns, the request being served is not interrupted
+		_ = conn.SetReadDeadline(time.Now())
+	}
+	h.wsConnsMtx.Unlock()
+
+	err := h.server.ShutdownWithContext(ctx)
+	if err != nil {
+		h.Logger.Error().Msgf("error while shutting down http server: %v", err)
+	}
+	wsClosed := make(chan struct{})
+	go func() {
+		h.wsConnsWg.Wait()
+		close(wsClosed)
+	}()
+	select {
+	case <-wsClosed:
+	case <-ctx.Done():
+		h.wsConnsMtx.Lock()
+		for conn := range h.wsConns {
+			_ = conn.Close()
+		}
+		h.wsConnsMtx.Unlock()
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+	return err
+}
+
 // validateRequest checks and validates incoming http request
 func validateRequest(r *http.Request) (int, error) {
 	if r.Method != http.MethodPost && r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions {
@@ -0,0 +1,205 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/aurora-is-near/relayer2-base/log"
+	"github.com/aurora-is-near/relayer2-base/utils"
+)
+
+const (
+	ipcOutputBuffer     = 100
+	ipcReadBuffer       = 4 * 1024
+	ipcMessageSizeLimit = wsMessageSizeLimit
+)
+
+var ipcNewline = []byte{'\n'}
+
+// IpcServer serves JSON-RPC over a unix domain socket, requests and responses are newline-delimited JSON. Each
+// connection is resolved as a websocket connection, so that it supports subscriptions.
+type IpcServer struct {
+	Logger   *log.Logger
+	Config   IpcConfig
+	resolver Resolver
+	listener net.Listener
+	connsMtx sync.Mutex
+	conns    map[net.Conn]struct{}
+	connsWg  sync.WaitGroup
+}
+
+// IpcConfig holds the IPC configuration elements
+type IpcConfig struct {
+	// Path is the path of the socket file, an existing file is replaced
+	Path string
+}
+
+// Run creates the socket file and starts accepting connections
+func (s *IpcServer) Run(ctx context.Context, resolver Resolver) error {
+	s.Logger.Info().Msgf("starting IPC server on %s", s.Config.Path)
+	if err := os.MkdirAll(filepath.Dir(s.Config.Path), 0751); err != nil {
+		return err
+	}
+	// the file is left behind by a process which was not stopped gracefully
+	if err := os.Remove(s.Config.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	listener, err := net.Listen("unix", s.Config.Path)
+	if err != nil {
+		return err
+	}
+	// only the user running the relayer can connect
+	if err := os.Chmod(s.Config.Path, 0600); err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	s.resolver = resolver
+	s.listener = listener
+	s.conns = map[net.Conn]struct{}{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					s.Logger.Error().Err(err).Msg("error while accepting IPC connection")
+				}
+				return
+			}
+			if !s.track(conn) {
+				_ = conn.Close()
+				return
+			}
+			go s.serve(ctx, conn)
+		}
+	}()
+	return nil
+}
+
+// track registers the connection to be closed by Stop, returns false if the server is stopped
+func (s *IpcServer) track(conn net.Conn) bool {
+	s.connsMtx.Lock()
+	defer s.connsMtx.Unlock()
+	if s.conns == nil {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	s.connsWg.Add(1)
+	return true
+}
+
+func (s *IpcServer) untrack(conn net.Conn) {
+	s.connsMtx.Lock()
+	defer s.connsMtx.Unlock()
+	if s.conns != nil {
+		delete(s.conns, conn)
+	}
+	s.connsWg.Done()
+}
+
+// serve resolves the requests of the connection until the client closes it or the server is stopped
+func (s *IpcServer) serve(ctx context.Context, conn net.Conn) {
+	defer s.untrack(conn)
+	defer conn.Close()
+
+	wsCtx := &WebSocketContext{subscriptions: make(map[ID]*Subscription), subscriptionsMtx: sync.Mutex{}}
+	wsCtx.output = make(chan []byte, ipcOutputBuffer)
+	wsCtx.closed.Store(false)
+	openWsConn(s.resolver, wsCtx)
+
+	wsCtx.outputWg.Add(1)
+	go s.handleOutput(conn, wsCtx)
+
+	// the client is on the same host, loopback stands for its address
+	cCtx := utils.PutClientIpKey(ctx, net.IPv4(127, 0, 0, 1))
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, ipcReadBuffer), ipcMessageSizeLimit)
+	for scanner.Scan() {
+		message := bytes.TrimSpace(scanner.Bytes())
+		if len(message) == 0 {
+			continue
+		}
+		// the scanner reuses its buffer for the next message
+		message = append([]byte(nil), message...)
+		wsCtx.respond(s.resolver.ResolveWs(&cCtx, wsCtx, message))
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		s.Logger.Warn().Err(err).Msg("error while reading from IPC connection")
+	}
+
+	wsCtx.close()
+	wsCtx.outputWg.Wait()
+	s.resolver.CloseWsConn(wsCtx)
+}
+
+// handleOutput writes the responses and notifications of the connection, each one followed by a newline
+func (s *IpcServer) handleOutput(conn net.Conn, wsCtx *WebSocketContext) {
+	defer wsCtx.outputWg.Done()
+
+	for data := range wsCtx.output {
+		if !wsCtx.closed.Load() {
+			buffers := net.Buffers{data, ipcNewline}
+			if _, err := buffers.WriteTo(conn); err != nil {
+				s.Logger.Error().Err(err).Msg("error while writing to IPC connection")
+			}
+		}
+	}
+}
+
+// Stop closes the listener, which removes the socket file, and the open connections
+func (s *IpcServer) Stop() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Shutdown(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown closes the listener, which removes the socket file, and stops reading requests from the open connections.
+// The connections are closed once the responses of their in-flight requests are written, or when ctx is done.
+func (s *IpcServer) Shutdown(ctx context.Context) error {
+	s.Logger.Info().Msg("stopping IPC listener...")
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	if err != nil {
+		s.Logger.Error().Msgf("error while closing listener: %v", err)
+	}
+	s.connsMtx.Lock()
+	conns := s.conns
+	s.conns = nil
+	s.connsMtx.Unlock()
+	for conn := range conns {
+		if uc, ok := conn.(*net.UnixConn); ok {
+			_ = uc.CloseRead()
+		} else {
+			_ = conn.Close()
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		s.connsWg.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-ctx.Done():
+		for conn := range conns {
+			_ = conn.Close()
+		}
+		<-closed
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+	return err
+}
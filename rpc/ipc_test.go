@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/log"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvents struct {
+	closed chan struct{}
+}
+
+// Ticks sends the given number of notifications and waits for the subscription to be closed
+func (e *testEvents) Ticks(ctx context.Context, n int) (*ID, error) {
+	notifier, _ := NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := 0; i < n; i++ {
+			_ = notifier.Notify(sub.ID, i)
+		}
+		<-sub.Err()
+		close(e.closed)
+	}()
+	return &sub.ID, nil
+}
+
+type ipcMessage struct {
+	Id     *int                `json:"id"`
+	Result jsoniter.RawMessage `json:"result"`
+	Params struct {
+		Subscription string `json:"subscription"`
+		Result       int    `json:"result"`
+	} `json:"params"`
+}
+
+func TestIpcServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relayer.ipc")
+	ipc := &IpcServer{Logger: log.Log(), Config: IpcConfig{Path: path}}
+	srv := New(log.Log(), 10, WithTransport(ipc))
+	require.NoError(t, srv.RegisterEndpoints("test", &testService{}))
+	events := &testEvents{closed: make(chan struct{})}
+	require.NoError(t, srv.RegisterEvents("eth", events))
+	require.NoError(t, srv.Run(context.Background()))
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+	reader := bufio.NewReader(conn)
+	read := func() ipcMessage {
+		line, err := reader.ReadBytes('\n')
+		require.NoError(t, err)
+		var msg ipcMessage
+		require.NoError(t, jsoniter.Unmarshal(line, &msg))
+		return msg
+	}
+
+	// two requests in a single write, an empty line in between
+	_, err = conn.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["a"]}` + "\n\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"test_echo","params":["b"]}` + "\n"))
+	require.NoError(t, err)
+	assert.Equal(t, `"a"`, string(read().Result))
+	assert.Equal(t, `"b"`, string(read().Result))
+
+	_, err = conn.Write([]byte(`{"jsonrpc":"2.0","id":3,"method":"eth_subscribe","params":["ticks",2]}` + "\n"))
+	require.NoError(t, err)
+	msg := read()
+	require.NotNil(t, msg.Id)
+	assert.Equal(t, 3, *msg.Id)
+	var subId string
+	require.NoError(t, jsoniter.Unmarshal(msg.Result, &subId))
+	for i := 0; i < 2; i++ {
+		msg = read()
+		assert.Equal(t, subId, msg.Params.Subscription)
+		assert.Equal(t, i, msg.Params.Result)
+	}
+
+	// stopping the server closes the connection and its subscriptions, and removes the socket file
+	srv.Close()
+	select {
+	case <-events.closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscription is not closed")
+	}
+	_, err = net.Dial("unix", path)
+	assert.Error(t, err)
+}
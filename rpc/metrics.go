@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/probe"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const unknownMethodLabel = "unknown"
+
+// Metrics holds the RPC server metrics registered through the probe registry. All methods are safe to call on a nil
+// Metrics, so that the server does not need to check whether metrics are enabled.
+type Metrics struct {
+	requests      *prometheus.CounterVec
+	errors        *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	inFlight      *prometheus.GaugeVec
+	batchSize     prometheus.Observer
+	wsConnections prometheus.Gauge
+	subscriptions prometheus.Gauge
+}
+
+// NewMetrics registers the RPC server metrics, returns nil if the probe is disabled or not started
+func NewMetrics() *Metrics {
+	requests, ok := probe.SetCounterVec(probe.MetricConfig{
+		Id:         "rpc_requests",
+		Name:       "rpc_requests_total",
+		Help:       "Number of served JSON-RPC requests by method",
+		LabelNames: []string{"method"},
+	})
+	if !ok {
+		return nil
+	}
+	errors, _ := probe.SetCounterVec(probe.MetricConfig{
+		Id:         "rpc_errors",
+		Name:       "rpc_errors_total",
+		Help:       "Number of JSON-RPC error responses by method and error code",
+		LabelNames: []string{"method", "code"},
+	})
+	latency, _ := probe.SetHistogramVec(probe.MetricConfig{
+		Id:         "rpc_latency",
+		Name:       "rpc_request_duration_seconds",
+		Help:       "Time spent serving JSON-RPC requests by method",
+		Buckets:    []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		LabelNames: []string{"method"},
+	})
+	inFlight, _ := probe.SetGaugeVec(probe.MetricConfig{
+		Id:         "rpc_in_flight",
+		Name:       "rpc_requests_in_flight",
+		Help:       "Number of JSON-RPC requests being served by method",
+		LabelNames: []string{"method"},
+	})
+	batchSize, _ := probe.SetHistogramVec(probe.MetricConfig{
+		Id:      "rpc_batch_size",
+		Name:    "rpc_batch_size",
+		Help:    "Number of requests in JSON-RPC batches",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 11),
+	})
+	wsConnections, _ := probe.SetGaugeVec(probe.MetricConfig{
+		Id:   "rpc_ws_connections",
+		Name: "rpc_ws_connections",
+		Help: "Number of open websocket connections",
+	})
+	subscriptions, _ := probe.SetGaugeVec(probe.MetricConfig{
+		Id:   "rpc_subscriptions",
+		Name: "rpc_subscriptions",
+		Help: "Number of active websocket subscriptions",
+	})
+
+	return &Metrics{
+		requests:      requests,
+		errors:        errors,
+		latency:       latency,
+		inFlight:      inFlight,
+		batchSize:     batchSize.WithLabelValues(),
+		wsConnections: wsConnections.WithLabelValues(),
+		subscriptions: subscriptions.WithLabelValues(),
+	}
+}
+
+// begin records the start of a call to the given method and returns the function recording its end with the error
+// code of the response
+func (m *Metrics) begin(method string) func(errorCode int64) {
+	if m == nil {
+		return func(int64) {}
+	}
+	start := time.Now()
+	m.inFlight.WithLabelValues(method).Inc()
+	return func(errorCode int64) {
+		m.inFlight.WithLabelValues(method).Dec()
+		m.requests.WithLabelValues(method).Inc()
+		m.latency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		if errorCode != 0 {
+			m.errors.WithLabelValues(method, strconv.FormatInt(errorCode, 10)).Inc()
+		}
+	}
+}
+
+func (m *Metrics) observeBatch(size int) {
+	if m != nil {
+		m.batchSize.Observe(float64(size))
+	}
+}
+
+func (m *Metrics) addWsConnections(n int) {
+	if m != nil {
+		m.wsConnections.Add(float64(n))
+	}
+}
+
+func (m *Metrics) addSubscriptions(n int) {
+	if m != nil {
+		m.subscriptions.Add(float64(n))
+	}
+}
+
+// WithMetrics enables the given metrics on the server. The metrics middleware is placed on the middlewares chain at
+// the time of the call, so it also measures the middlewares placed before it (e.g. rejected calls of the rate limiter).
+func (r *RpcServer) WithMetrics(m *Metrics) {
+	if m == nil {
+		return
+	}
+	r.metrics = m
+	r.WithMiddleware(r.metricsMiddleware)
+}
+
+func (r *RpcServer) metricsMiddleware(next RpcHandler) RpcHandler {
+	return func(ctx *context.Context, rpcCtx *RpcContext) *RpcContext {
+		end := r.metrics.begin(r.methodLabel(rpcCtx))
+		rpcCtx = next(ctx, rpcCtx)
+		end(rpcCtx.errorCode)
+		return rpcCtx
+	}
+}
+
+// methodLabel returns the method name of the request if it is served by the server, unknown otherwise, to keep the
+// label cardinality bounded whatever the clients send
+func (r *RpcServer) methodLabel(rpcCtx *RpcContext) string {
+	method := strings.ToLower(rpcCtx.parsedBody.Method.Str())
+	if rpcCtx.parsedBody.IsSubscribe() || rpcCtx.parsedBody.IsUnsubscribe() {
+		return method
+	}
+	r.mu.RLock()
+	_, ok := r.serviceMap.services[method]
+	r.mu.RUnlock()
+	if !ok {
+		return unknownMethodLabel
+	}
+	return method
+}
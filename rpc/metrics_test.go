@@ -0,0 +1,44 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aurora-is-near/relayer2-base/log"
+	"github.com/aurora-is-near/relayer2-base/probe"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testService struct{}
+
+func (s *testService) Echo(_ context.Context, v string) (string, error) { return v, nil }
+
+func TestMetrics(t *testing.T) {
+	viper.Set("probe.enable", true)
+	viper.Set("probe.server.address", "127.0.0.1:0")
+	probe.Start()
+	t.Cleanup(func() {
+		probe.Stop()
+		viper.Set("probe.enable", false)
+	})
+
+	m := NewMetrics()
+	require.NotNil(t, m)
+	srv := New(log.Log(), 10)
+	require.NoError(t, srv.RegisterEndpoints("test", &testService{}))
+	srv.WithMetrics(m)
+
+	ctx := context.Background()
+	srv.ResolveHttp(&ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["a"]}`))
+	srv.ResolveHttp(&ctx, []byte(`[{"jsonrpc":"2.0","id":1,"method":"test_echo","params":[]},{"jsonrpc":"2.0","id":2,"method":"test_nope","params":[]}]`))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.requests.WithLabelValues("test_echo")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.requests.WithLabelValues(unknownMethodLabel)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.errors.WithLabelValues("test_echo", "-32602")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.errors.WithLabelValues(unknownMethodLabel, "-32601")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.inFlight.WithLabelValues("test_echo")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.latency))
+}
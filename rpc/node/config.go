@@ -6,6 +6,8 @@ import (
 
 	"github.com/aurora-is-near/relayer2-base/cmdutils"
 	"github.com/aurora-is-near/relayer2-base/log"
+	"github.com/aurora-is-near/relayer2-base/rpc"
+	"github.com/aurora-is-near/relayer2-base/rpc/node/events"
 
 	"github.com/spf13/viper"
 )
@@ -21,21 +23,42 @@ var (
 	defaultHttpTimeout        time.Duration = 300
 	defaultWsHandshakeTimeout time.Duration = 10
 	defaultMaxBatchRequests   uint          = 1000
+	defaultShutdownTimeout    time.Duration = 10
 	DefaultPathPrefix         string        = "*"
+
+	defaultRateLimitIpRate      float64 = 100
+	defaultRateLimitIpBurst     float64 = 200
+	defaultRateLimitMethodRate  float64 = 50
+	defaultRateLimitMethodBurst float64 = 100
+	defaultRateLimitIdleTimeout uint    = 600
+	defaultRateLimitWeights             = map[string]float64{
+		"eth_getlogs":            10,
+		"eth_call":               5,
+		"eth_estimategas":        5,
+		"debug_tracetransaction": 20,
+		"eth_getblockbynumber":   2,
+		"eth_getblockbyhash":     2,
+		"eth_getfilterchanges":   2,
+	}
 )
 
 type Config struct {
-	HttpPort           int16         `mapstructure:"httpPort"`
-	HttpHost           string        `mapstructure:"httpHost"`
-	HttpCors           []string      `mapstructure:"httpCors"`
-	HttpCompress       bool          `mapstructure:"httpCompress"`
-	HttpTimeout        time.Duration `mapstructure:"httpTimeout"`
-	HttpPathPrefix     string        `mapstructure:"httpPathPrefix"`
-	WsPort             int16         `mapstructure:"wsPort"`
-	WsHost             string        `mapstructure:"wsHost"`
-	WsPathPrefix       string        `mapstructure:"wsPathPrefix"`
-	WsHandshakeTimeout time.Duration `mapstructure:"wsHandshakeTimeout"`
-	MaxBatchRequests   uint          `mapstructure:"maxBatchRequests"`
+	HttpPort           int16               `mapstructure:"httpPort"`
+	HttpHost           string              `mapstructure:"httpHost"`
+	HttpCors           []string            `mapstructure:"httpCors"`
+	HttpCompress       bool                `mapstructure:"httpCompress"`
+	HttpTimeout        time.Duration       `mapstructure:"httpTimeout"`
+	HttpPathPrefix     string              `mapstructure:"httpPathPrefix"`
+	WsPort             int16               `mapstructure:"wsPort"`
+	WsHost             string              `mapstructure:"wsHost"`
+	WsPathPrefix       string              `mapstructure:"wsPathPrefix"`
+	WsHandshakeTimeout time.Duration       `mapstructure:"wsHandshakeTimeout"`
+	IpcPath            string              `mapstructure:"ipcPath"`
+	MaxBatchRequests   uint                `mapstructure:"maxBatchRequests"`
+	ShutdownTimeout    time.Duration       `mapstructure:"shutdownTimeout"`
+	RateLimit          rpc.RateLimitConfig `mapstructure:"rateLimit"`
+	EnableMetrics      bool                `mapstructure:"enableMetrics"`
+	Events             events.Config       `mapstructure:"events"`
 }
 
 // httpEndpoint resolves an HTTP endpoint based on the configured host interface
@@ -67,7 +90,26 @@ func defaultConfig() *Config {
 		WsPathPrefix:       DefaultPathPrefix,
 		WsHandshakeTimeout: defaultWsHandshakeTimeout,
 		MaxBatchRequests:   defaultMaxBatchRequests,
+		ShutdownTimeout:    defaultShutdownTimeout,
+		RateLimit: rpc.RateLimitConfig{
+			Enabled:            false,
+			IpRate:             defaultRateLimitIpRate,
+			IpBurst:            defaultRateLimitIpBurst,
+			MethodRate:         defaultRateLimitMethodRate,
+			MethodBurst:        defaultRateLimitMethodBurst,
+			MethodWeights:      copyWeights(defaultRateLimitWeights),
+			IdleTimeoutSeconds: defaultRateLimitIdleTimeout,
+		},
+		Events: events.DefaultConfig(),
+	}
+}
+
+func copyWeights(weights map[string]float64) map[string]float64 {
+	c := make(map[string]float64, len(weights))
+	for k, v := range weights {
+		c[k] = v
 	}
+	return c
 }
 
 func GetConfig() *Config {
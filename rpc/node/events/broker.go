@@ -1,14 +1,14 @@
 package events
 
 import (
-	"bytes"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aurora-is-near/relayer2-base/broker"
 	"github.com/aurora-is-near/relayer2-base/log"
 	"github.com/aurora-is-near/relayer2-base/rpc"
-	"github.com/aurora-is-near/relayer2-base/types/common"
+	errs "github.com/aurora-is-near/relayer2-base/types/errors"
 	"github.com/aurora-is-near/relayer2-base/types/event"
 	"github.com/aurora-is-near/relayer2-base/types/request"
 )
@@ -20,6 +20,8 @@ const (
 	LogsChSize = 5
 	// NewHeadsChSize is the size of channel listening to NewHeads types.
 	NewHeadsChSize = 5
+	// PendingTransactionsChSize is the size of channel listening to PendingTransactions types.
+	PendingTransactionsChSize = 16
 )
 
 const (
@@ -29,8 +31,22 @@ const (
 	NewHeadsSubscription
 	// LogsSubscription queries for new or removed (chain reorg) logs
 	LogsSubscription
+	// PendingTransactionsSubscription tracks the transactions submitted by the relayer
+	PendingTransactionsSubscription
 )
 
+func (t Type) String() string {
+	switch t {
+	case NewHeadsSubscription:
+		return "newHeads"
+	case LogsSubscription:
+		return "logs"
+	case PendingTransactionsSubscription:
+		return "newPendingTransactions"
+	}
+	return "unknown"
+}
+
 type EventSubscription struct {
 	id         rpc.ID
 	typ        Type
@@ -38,6 +54,29 @@ type EventSubscription struct {
 	logOpts    request.LogSubscriptionOptions
 	newHeadsCh chan event.Block
 	logsCh     chan event.Logs
+	pendingCh  chan event.Transaction
+	// the events are queued by the EventBroker and forwarded to the channels of the subscriber by a goroutine of
+	// the subscription, so that a slow subscriber does not hold up the others
+	newHeadsQueue chan event.Block
+	logsQueue     chan event.Logs
+	pendingQueue  chan event.Transaction
+	// done is closed once the subscription is removed from the EventBroker
+	done    chan struct{}
+	err     chan error
+	dropped uint64
+}
+
+func newEventSubscription(typ Type) *EventSubscription {
+	return &EventSubscription{
+		id:         rpc.NewID(),
+		typ:        typ,
+		created:    time.Now(),
+		newHeadsCh: make(chan event.Block),
+		logsCh:     make(chan event.Logs),
+		pendingCh:  make(chan event.Transaction),
+		done:       make(chan struct{}),
+		err:        make(chan error, 1),
+	}
 }
 
 // GetId returns identifier of the EventSubscription which implements broker.Subscription
@@ -60,46 +99,113 @@ func (es *EventSubscription) GetLogsCh() chan event.Logs {
 	return es.logsCh
 }
 
+// GetPendingTransactionsCh returns the pending transactions types channel of the EventSubscription which implements
+// broker.Subscription
+func (es *EventSubscription) GetPendingTransactionsCh() chan event.Transaction {
+	return es.pendingCh
+}
+
+// Err returns the channel receiving the error if the EventBroker closes the subscription, see Disconnect
+func (es *EventSubscription) Err() <-chan error {
+	return es.err
+}
+
+// Dropped returns the number of events not delivered to the subscription since its queue was full
+func (es *EventSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&es.dropped)
+}
+
+// Queued returns the number of events waiting to be forwarded to the channel of the subscription
+func (es *EventSubscription) Queued() int {
+	switch es.typ {
+	case NewHeadsSubscription:
+		return len(es.newHeadsQueue)
+	case LogsSubscription:
+		return len(es.logsQueue)
+	case PendingTransactionsSubscription:
+		return len(es.pendingQueue)
+	}
+	return 0
+}
+
+// Stats is a snapshot of the EventBroker subscriptions and slow consumer counters
+type Stats struct {
+	NewHeadsSubscriptions            int
+	LogsSubscriptions                int
+	PendingTransactionsSubscriptions int
+	// Dropped is the number of events not delivered since the queue of the subscriber was full
+	Dropped uint64
+	// Disconnected is the number of subscriptions closed by the Disconnect policy
+	Disconnected uint64
+}
+
 // EventBroker offers support to manage types subscriptions and broadcast the incoming events to
 // subscribed objects.
+//
+// The events are queued for each subscriber up to Config.QueueSize, once the queue is full the event is handled
+// according to Config.SlowConsumerPolicy.
 type EventBroker struct {
+	Config            Config
 	l                 *log.Logger
-	stopCh            chan bool
+	metrics           *metrics
+	stopCh            chan struct{}
+	stopOnce          sync.Once
 	publishNewHeadsCh chan event.Block
 	publishLogsCh     chan event.Logs
-	subNewHeadsCh     chan broker.Subscription
-	subLogsCh         chan broker.Subscription
+	publishPendingCh  chan event.Transaction
+	subNewHeadsCh     chan *EventSubscription
+	subLogsCh         chan *EventSubscription
+	subPendingCh      chan *EventSubscription
 	unsubNewHeadsCh   chan broker.Subscription
 	unsubLogsCh       chan broker.Subscription
-	DebugInfo         chan int
+	unsubPendingCh    chan broker.Subscription
+	statsCh           chan chan Stats
 }
 
-// NewEventBroker creates a new EventBroker object
+// NewEventBroker creates a new EventBroker object with the default configuration
 func NewEventBroker() *EventBroker {
+	return NewEventBrokerWithConfig(DefaultConfig())
+}
+
+// NewEventBrokerWithConfig creates a new EventBroker object, an unknown slow consumer policy or a non-positive queue
+// size falls back to the default one
+func NewEventBrokerWithConfig(config Config) *EventBroker {
+	l := log.Log()
+	policy, err := ParseSlowConsumerPolicy(string(config.SlowConsumerPolicy))
+	if err != nil {
+		l.Warn().Err(err).Msgf("falling back to slow consumer policy %s", defaultSlowConsumerPolicy)
+		policy = defaultSlowConsumerPolicy
+	}
+	config.SlowConsumerPolicy = policy
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
 	return &EventBroker{
-		l:                 log.Log(),
-		stopCh:            make(chan bool, 1),
+		Config:            config,
+		l:                 l,
+		metrics:           newMetrics(),
+		stopCh:            make(chan struct{}),
 		publishNewHeadsCh: make(chan event.Block, NewHeadsChSize),
 		publishLogsCh:     make(chan event.Logs, LogsChSize),
-		subNewHeadsCh:     make(chan broker.Subscription),
-		subLogsCh:         make(chan broker.Subscription),
+		publishPendingCh:  make(chan event.Transaction, PendingTransactionsChSize),
+		subNewHeadsCh:     make(chan *EventSubscription),
+		subLogsCh:         make(chan *EventSubscription),
+		subPendingCh:      make(chan *EventSubscription),
 		unsubNewHeadsCh:   make(chan broker.Subscription),
 		unsubLogsCh:       make(chan broker.Subscription),
-		DebugInfo:         make(chan int),
+		unsubPendingCh:    make(chan broker.Subscription),
+		statsCh:           make(chan chan Stats),
 	}
 }
 
 // SubscribeNewHeads creates a new subscription and signals the
 // EventBroker subscription channel to handle the subscription map
 func (eb *EventBroker) SubscribeNewHeads(ch chan event.Block) broker.Subscription {
-	sub := &EventSubscription{
-		id:         rpc.NewID(),
-		typ:        NewHeadsSubscription,
-		created:    time.Now(),
-		newHeadsCh: ch,
-		logsCh:     make(chan event.Logs),
-	}
-	eb.subNewHeadsCh <- sub
+	sub := newEventSubscription(NewHeadsSubscription)
+	sub.newHeadsCh = ch
+	sub.newHeadsQueue = make(chan event.Block, eb.Config.QueueSize)
+	go forward(eb, sub, sub.newHeadsQueue, ch)
+	send(eb, eb.subNewHeadsCh, sub)
 	eb.l.Debug().Msgf("new subscription request to New Heads with Id: [%s]", sub.id)
 	return sub
 }
@@ -107,127 +213,231 @@ func (eb *EventBroker) SubscribeNewHeads(ch chan event.Block) broker.Subscriptio
 // SubscribeLogs creates a new subscription and signals the
 // EventBroker subscription channel to handle the subscription map
 func (eb *EventBroker) SubscribeLogs(opts request.LogSubscriptionOptions, ch chan event.Logs) broker.Subscription {
-	sub := &EventSubscription{
-		id:         rpc.NewID(),
-		typ:        LogsSubscription,
-		created:    time.Now(),
-		logOpts:    opts,
-		newHeadsCh: make(chan event.Block),
-		logsCh:     ch,
-	}
-	eb.subLogsCh <- sub
+	sub := newEventSubscription(LogsSubscription)
+	sub.logOpts = opts
+	sub.logsCh = ch
+	sub.logsQueue = make(chan event.Logs, eb.Config.QueueSize)
+	go forward(eb, sub, sub.logsQueue, ch)
+	send(eb, eb.subLogsCh, sub)
 	eb.l.Debug().Msgf("new subscription request to Logs with Id: [%s]", sub.id)
 	return sub
 }
 
+// SubscribePendingTransactions creates a new subscription and signals the
+// EventBroker subscription channel to handle the subscription map
+func (eb *EventBroker) SubscribePendingTransactions(ch chan event.Transaction) broker.Subscription {
+	sub := newEventSubscription(PendingTransactionsSubscription)
+	sub.pendingCh = ch
+	sub.pendingQueue = make(chan event.Transaction, eb.Config.QueueSize)
+	go forward(eb, sub, sub.pendingQueue, ch)
+	send(eb, eb.subPendingCh, sub)
+	eb.l.Debug().Msgf("new subscription request to Pending Transactions with Id: [%s]", sub.id)
+	return sub
+}
+
 // UnsubscribeFromNewHeads signals the EventBroker's related channel
 // to delete the subscription
 func (eb *EventBroker) UnsubscribeFromNewHeads(sub broker.Subscription) {
-	eb.unsubNewHeadsCh <- sub
+	send(eb, eb.unsubNewHeadsCh, sub)
 	eb.l.Debug().Msgf("unsubscription request to New Heads with Id: [%s]", sub.GetId())
 }
 
 // UnsubscribeFromLogs signals the EventBroker's related channel
 // to delete the subscription
 func (eb *EventBroker) UnsubscribeFromLogs(sub broker.Subscription) {
-	eb.unsubLogsCh <- sub
+	send(eb, eb.unsubLogsCh, sub)
 	eb.l.Debug().Msgf("unsubscription request to Logs with Id: [%s]", sub.GetId())
 }
 
+// UnsubscribeFromPendingTransactions signals the EventBroker's related channel
+// to delete the subscription
+func (eb *EventBroker) UnsubscribeFromPendingTransactions(sub broker.Subscription) {
+	send(eb, eb.unsubPendingCh, sub)
+	eb.l.Debug().Msgf("unsubscription request to Pending Transactions with Id: [%s]", sub.GetId())
+}
+
+// Stats returns the current number of subscriptions and the slow consumer counters, it blocks until the EventBroker
+// is started and returns zero counters once it is closed
+func (eb *EventBroker) Stats() Stats {
+	ch := make(chan Stats, 1)
+	if !send(eb, eb.statsCh, ch) {
+		return Stats{}
+	}
+	return <-ch
+}
+
+// Close stops the main loop, the subscribers are not closed and the calls made afterwards are no-ops
+func (eb *EventBroker) Close() error {
+	eb.stopOnce.Do(func() {
+		close(eb.stopCh)
+	})
+	return nil
+}
+
+// send hands v to the main loop, returns false if the EventBroker is closed
+func send[T any](eb *EventBroker, ch chan T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-eb.stopCh:
+		return false
+	}
+}
+
 // Start main loop of the EventBroker that receives and distributes the events.
 func (eb *EventBroker) Start() {
-	subsNewHeads := map[broker.SubID]broker.Subscription{}
-	subsLogs := map[broker.SubID]broker.Subscription{}
+	subsNewHeads := map[broker.SubID]*EventSubscription{}
+	subsLogs := map[broker.SubID]*EventSubscription{}
+	subsPending := map[broker.SubID]*EventSubscription{}
+	logIdx := newLogIndex()
+	var dropped, disconnected uint64
+	// delivered updates the counters, returns false if the subscription is closed by the Disconnect policy
+	delivered := func(res deliveryResult) bool {
+		switch res {
+		case droppedEvent:
+			dropped++
+		case disconnectedSub:
+			disconnected++
+			return false
+		}
+		return true
+	}
+	publish := func(subs map[broker.SubID]*EventSubscription, send func(*EventSubscription) deliveryResult) {
+		for id, sub := range subs {
+			if !delivered(send(sub)) {
+				delete(subs, id)
+			}
+		}
+	}
+	unsubscribe := func(subs map[broker.SubID]*EventSubscription, id broker.SubID) *EventSubscription {
+		sub, ok := subs[id]
+		if !ok {
+			return nil
+		}
+		delete(subs, id)
+		close(sub.done)
+		return sub
+	}
 	for {
 		select {
 		case <-eb.stopCh:
 			return
-		// this case is only for testing purposes
-		case req := <-eb.DebugInfo:
-			switch req {
-			case -1:
-				eb.DebugInfo <- len(subsNewHeads)
-			case -2:
-				eb.DebugInfo <- len(subsLogs)
+		case ch := <-eb.statsCh:
+			ch <- Stats{
+				NewHeadsSubscriptions:            len(subsNewHeads),
+				LogsSubscriptions:                len(subsLogs),
+				PendingTransactionsSubscriptions: len(subsPending),
+				Dropped:                          dropped,
+				Disconnected:                     disconnected,
 			}
 		case sub := <-eb.subNewHeadsCh:
 			subsNewHeads[sub.GetId()] = sub
 		case sub := <-eb.subLogsCh:
 			subsLogs[sub.GetId()] = sub
+			logIdx.add(sub)
 		case sub := <-eb.unsubNewHeadsCh:
-			delete(subsNewHeads, sub.GetId())
+			unsubscribe(subsNewHeads, sub.GetId())
+		case sub := <-eb.subPendingCh:
+			subsPending[sub.GetId()] = sub
 		case sub := <-eb.unsubLogsCh:
-			delete(subsLogs, sub.GetId())
-		case msg := <-eb.publishNewHeadsCh:
-			for _, v := range subsNewHeads {
-				// v.newHeadsCh is buffered, use non-blocking send to protect the broker:
-				// timeout preferred instead of default to be able to tolerate slight delays
-				select {
-				case v.GetNewHeadsCh() <- msg:
-				case <-time.After(10 * time.Millisecond):
-					eb.l.Warn().Msg("Publishing to New Heads channel fall into DEFAULT!")
-				}
+			if s := unsubscribe(subsLogs, sub.GetId()); s != nil {
+				logIdx.remove(s)
 			}
+		case sub := <-eb.unsubPendingCh:
+			unsubscribe(subsPending, sub.GetId())
+		case msg := <-eb.publishNewHeadsCh:
+			publish(subsNewHeads, func(sub *EventSubscription) deliveryResult {
+				return deliver(eb, sub, sub.newHeadsQueue, msg)
+			})
 		case logs := <-eb.publishLogsCh:
-			if len(logs) > 0 {
-				for _, s := range subsLogs {
-					matchedLogs := filterLogs(logs, s.GetLogsSubOpts())
-					if len(matchedLogs) > 0 {
-						// v.logsCh is buffered, use non-blocking send to protect the broker:
-						// timeout preferred instead of default to be able to tolerate slight delays
-						select {
-						case s.GetLogsCh() <- matchedLogs:
-						case <-time.After(10 * time.Millisecond):
-							eb.l.Warn().Msg("Publishing to Logs channel fall into DEFAULT!")
-						}
-					}
+			for sub, matchedLogs := range logIdx.match(logs) {
+				if !delivered(deliver(eb, sub, sub.logsQueue, matchedLogs)) {
+					delete(subsLogs, sub.GetId())
+					logIdx.remove(sub)
 				}
 			}
+		case tx := <-eb.publishPendingCh:
+			publish(subsPending, func(sub *EventSubscription) deliveryResult {
+				return deliver(eb, sub, sub.pendingQueue, tx)
+			})
 		}
 	}
 }
 
-// PublishNewHeads provides publish API for new block head types. Implements broker.Broker interface
-func (eb *EventBroker) PublishNewHeads(b event.Block) {
-	eb.publishNewHeadsCh <- b
-}
+type deliveryResult byte
 
-// PublishLogs provides publish API for logs types. Implements broker.Broker interface
-func (eb *EventBroker) PublishLogs(l event.Logs) {
-	eb.publishLogsCh <- l
-}
+const (
+	deliveredEvent deliveryResult = iota
+	droppedEvent
+	disconnectedSub
+)
+
+// deliver queues the event for the subscriber without blocking, the slow consumer policy is applied if its queue is
+// full. A subscription closed by the Disconnect policy is done, the caller must remove it.
+func deliver[T any](eb *EventBroker, sub *EventSubscription, queue chan T, ev T) deliveryResult {
+	select {
+	case queue <- ev:
+		return deliveredEvent
+	default:
+	}
 
-// filterLogs creates a slice of Log Response matching the given criteria.
-func filterLogs(logs event.Logs, opts request.LogSubscriptionOptions) event.Logs {
-	var ret event.Logs
-Logs:
-	for _, log := range logs {
-		if len(opts.Address) > 0 && !includes(opts.Address, log.Address.Hex()) {
-			continue
+	switch eb.Config.SlowConsumerPolicy {
+	case Disconnect:
+		eb.l.Warn().Msgf("closing slow %s subscription with Id: [%s]", sub.typ, sub.id)
+		eb.metrics.addDisconnect(sub.typ)
+		sub.err <- &errs.SlowConsumerError{}
+		close(sub.done)
+		return disconnectedSub
+	case DropOldest:
+		// the broker is the only sender, so there is room for the event once the oldest one is taken out, unless the
+		// forwarding goroutine took it first
+		select {
+		case <-queue:
+		default:
 		}
-		for i, sub := range opts.Topics {
-			match := len(sub) == 0 // empty rule set == wildcard
-			for _, topic := range sub {
-				// empty rule set == wildcard. Otherwise, check if topic index of opts fits in the number of topics in received log and if it fits check topics for equality
-				if len(topic.Content) == 0 || (i < len(log.Topics) && bytes.Equal(log.Topics[i].Bytes(), topic.Bytes())) {
-					match = true
-					break
-				}
-			}
-			if !match {
-				continue Logs
-			}
+		select {
+		case queue <- ev:
+		default:
 		}
-		ret = append(ret, log)
 	}
-	return ret
+	eb.l.Debug().Msgf("dropping event of slow %s subscription with Id: [%s]", sub.typ, sub.id)
+	eb.metrics.addDropped(sub.typ)
+	atomic.AddUint64(&sub.dropped, 1)
+	return droppedEvent
 }
 
-func includes(addresses []common.Address, address string) bool {
-	for _, addr := range addresses {
-		if strings.EqualFold(addr.Hex(), address) {
-			return true
+// forward sends the queued events of the subscription to its channel until the subscription is done or the
+// EventBroker is closed
+func forward[T any](eb *EventBroker, sub *EventSubscription, queue chan T, ch chan T) {
+	for {
+		select {
+		case ev := <-queue:
+			select {
+			case ch <- ev:
+			case <-sub.done:
+				return
+			case <-eb.stopCh:
+				return
+			}
+		case <-sub.done:
+			return
+		case <-eb.stopCh:
+			return
 		}
 	}
-	return false
+}
+
+// PublishNewHeads provides publish API for new block head types. Implements broker.Broker interface
+func (eb *EventBroker) PublishNewHeads(b event.Block) {
+	send(eb, eb.publishNewHeadsCh, b)
+}
+
+// PublishLogs provides publish API for logs types. Implements broker.Broker interface
+func (eb *EventBroker) PublishLogs(l event.Logs) {
+	send(eb, eb.publishLogsCh, l)
+}
+
+// PublishPendingTransaction provides publish API for pending transaction types. Implements broker.Broker interface
+func (eb *EventBroker) PublishPendingTransaction(tx event.Transaction) {
+	send(eb, eb.publishPendingCh, tx)
 }
@@ -8,12 +8,14 @@ import (
 	"github.com/aurora-is-near/relayer2-base/broker"
 	"github.com/aurora-is-near/relayer2-base/rpc/node/events"
 	"github.com/aurora-is-near/relayer2-base/types/common"
+	errs "github.com/aurora-is-near/relayer2-base/types/errors"
 	"github.com/aurora-is-near/relayer2-base/types/event"
 	"github.com/aurora-is-near/relayer2-base/types/primitives"
 	"github.com/aurora-is-near/relayer2-base/types/request"
 	"github.com/aurora-is-near/relayer2-base/types/response"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -456,6 +458,92 @@ func TestBrokerReturnsCorrectEventsWithAddress(t *testing.T) {
 	time.Sleep(1 * time.Second)
 }
 
+func TestBrokerSlowConsumerPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy       events.SlowConsumerPolicy
+		received     []primitives.HexUint
+		dropped      uint64
+		disconnected uint64
+	}{
+		{policy: events.DropNewest, received: []primitives.HexUint{0, 1, 2}, dropped: 2},
+		{policy: events.DropOldest, received: []primitives.HexUint{0, 3, 4}, dropped: 2},
+		{policy: events.Disconnect, disconnected: 1},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			eb := events.NewEventBrokerWithConfig(events.Config{SlowConsumerPolicy: tc.policy, QueueSize: 2})
+			go eb.Start()
+
+			// nobody reads the channel, so the first head is held by the forwarding goroutine and the last two heads
+			// do not fit in the queue
+			ch := make(chan event.Block)
+			sub := eb.SubscribeNewHeads(ch).(*events.EventSubscription)
+			eb.PublishNewHeads(&response.Block{Number: 0})
+			require.Eventually(t, func() bool { return eb.Stats().NewHeadsSubscriptions == 1 && sub.Queued() == 0 },
+				time.Second, 10*time.Millisecond)
+			for i := 1; i < 5; i++ {
+				eb.PublishNewHeads(&response.Block{Number: primitives.HexUint(i)})
+			}
+			assert.Eventually(t, func() bool {
+				stats := eb.Stats()
+				return stats.Dropped == tc.dropped && stats.Disconnected == tc.disconnected
+			}, time.Second, 10*time.Millisecond)
+			assert.Equal(t, tc.dropped, sub.Dropped())
+
+			select {
+			case err := <-sub.Err():
+				assert.Equal(t, events.Disconnect, tc.policy)
+				assert.IsType(t, &errs.SlowConsumerError{}, err)
+				numSubsNH, _ := getNumberOfSubscriptions(eb)
+				assert.Equal(t, 0, numSubsNH)
+				return
+			default:
+				assert.NotEqual(t, events.Disconnect, tc.policy)
+			}
+
+			var received []primitives.HexUint
+			for len(received) < len(tc.received) {
+				select {
+				case h := <-ch:
+					received = append(received, h.Number)
+				case <-time.After(time.Second):
+					t.Fatalf("received %v, expected %v", received, tc.received)
+				}
+			}
+			assert.Equal(t, tc.received, received)
+		})
+	}
+
+	eb := events.NewEventBrokerWithConfig(events.Config{SlowConsumerPolicy: "DISCONNECT"})
+	assert.Equal(t, events.Disconnect, eb.Config.SlowConsumerPolicy)
+	eb = events.NewEventBrokerWithConfig(events.Config{SlowConsumerPolicy: "block"})
+	assert.Equal(t, events.DropNewest, eb.Config.SlowConsumerPolicy)
+}
+
+func TestBrokerSlowSubscriberDoesNotBlockOthers(t *testing.T) {
+	eb := events.NewEventBrokerWithConfig(events.Config{QueueSize: 4})
+	go eb.Start()
+	defer eb.Close()
+
+	// the stuck subscriber never reads its channel
+	eb.SubscribeNewHeads(make(chan event.Block))
+	ch := make(chan event.Block)
+	eb.SubscribeNewHeads(ch)
+	require.Eventually(t, func() bool { return eb.Stats().NewHeadsSubscriptions == 2 }, time.Second, 10*time.Millisecond)
+
+	const n = 100
+	for i := 0; i < n; i++ {
+		eb.PublishNewHeads(&response.Block{Number: primitives.HexUint(i)})
+		select {
+		case h := <-ch:
+			require.Equal(t, primitives.HexUint(i), h.Number)
+		case <-time.After(time.Second):
+			t.Fatalf("head %d is not received", i)
+		}
+	}
+	// the stuck subscriber holds one head and queues four of them
+	assert.Equal(t, uint64(n-5), eb.Stats().Dropped, "the heads of the stuck subscriber must be dropped")
+}
+
 func createClientAndSubscribeLogs(eb *events.EventBroker, eventCounterCh chan int, subOptions request.LogSubscriptionOptions) broker.Subscription {
 	clientLogCh := make(chan event.Logs)
 	subsLog := eb.SubscribeLogs(subOptions, clientLogCh)
@@ -493,12 +581,8 @@ func createClientAndSubscribe(eb *events.EventBroker, eventCounterCh chan int) (
 }
 
 func getNumberOfSubscriptions(eb *events.EventBroker) (int, int) {
-	eb.DebugInfo <- -1
-	numSubsNH := <-eb.DebugInfo
-	eb.DebugInfo <- -2
-	numSubsLog := <-eb.DebugInfo
-
-	return numSubsNH, numSubsLog
+	stats := eb.Stats()
+	return stats.NewHeadsSubscriptions, stats.LogsSubscriptions
 }
 
 func GenerateLogResponse() *response.Log {
@@ -524,3 +608,25 @@ func randomBytes(n int) []byte {
 	}
 	return b
 }
+
+func TestBrokerClose(t *testing.T) {
+	eb := events.NewEventBroker()
+	stopped := make(chan struct{})
+	go func() {
+		eb.Start()
+		close(stopped)
+	}()
+	sub := eb.SubscribeNewHeads(make(chan event.Block, 1))
+	require.NoError(t, eb.Close())
+	require.NoError(t, eb.Close())
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("broker is not stopped")
+	}
+
+	// the calls made after Close do not block
+	eb.PublishNewHeads(&response.Block{})
+	eb.UnsubscribeFromNewHeads(sub)
+	assert.Equal(t, events.Stats{}, eb.Stats())
+}
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SlowConsumerPolicy tells what the broker does with an event if the queue of a subscriber is full
+type SlowConsumerPolicy string
+
+const (
+	// DropNewest drops the event being published
+	DropNewest SlowConsumerPolicy = "dropNewest"
+	// DropOldest drops the oldest event in the queue to make room for the event being published
+	DropOldest SlowConsumerPolicy = "dropOldest"
+	// Disconnect closes the subscription, see broker.Subscription.Err
+	Disconnect SlowConsumerPolicy = "disconnect"
+
+	defaultSlowConsumerPolicy = DropNewest
+	defaultQueueSize          = 64
+)
+
+const (
+	// LocalBackend delivers the events to the subscribers of the same process, see EventBroker
+	LocalBackend = "local"
+	// NatsBackend delivers the events to the subscribers of all the relayers connected to a NATS server, see
+	// NatsBroker
+	NatsBackend = "nats"
+
+	defaultNatsUrl           = "nats://127.0.0.1:4222"
+	defaultNatsSubjectPrefix = "relayer.events"
+)
+
+type Config struct {
+	// Backend is either LocalBackend or NatsBackend
+	Backend            string             `mapstructure:"backend"`
+	SlowConsumerPolicy SlowConsumerPolicy `mapstructure:"slowConsumerPolicy"`
+	// QueueSize is the number of events queued for a subscriber which is not keeping up before applying the policy
+	QueueSize int        `mapstructure:"queueSize"`
+	Nats      NatsConfig `mapstructure:"nats"`
+}
+
+type NatsConfig struct {
+	Url string `mapstructure:"url"`
+	// SubjectPrefix separates the events of the relayers sharing a NATS server but serving different networks
+	SubjectPrefix string `mapstructure:"subjectPrefix"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Backend:            LocalBackend,
+		SlowConsumerPolicy: defaultSlowConsumerPolicy,
+		QueueSize:          defaultQueueSize,
+		Nats: NatsConfig{
+			Url:           defaultNatsUrl,
+			SubjectPrefix: defaultNatsSubjectPrefix,
+		},
+	}
+}
+
+// ParseSlowConsumerPolicy returns the policy with the given case-insensitive name
+func ParseSlowConsumerPolicy(name string) (SlowConsumerPolicy, error) {
+	for _, p := range []SlowConsumerPolicy{DropNewest, DropOldest, Disconnect} {
+		if strings.EqualFold(name, string(p)) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown slow consumer policy %q, expected one of %s, %s, %s", name, DropNewest, DropOldest,
+		Disconnect)
+}
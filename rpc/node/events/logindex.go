@@ -0,0 +1,138 @@
+package events
+
+import (
+	"bytes"
+
+	"github.com/aurora-is-near/relayer2-base/broker"
+	"github.com/aurora-is-near/relayer2-base/types/event"
+	"github.com/aurora-is-near/relayer2-base/types/request"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+)
+
+type subscriptionSet map[broker.SubID]*EventSubscription
+
+// logIndex finds the log subscriptions matching the published logs without checking each log against every
+// subscription. A subscription is indexed once: by its addresses if it has any, otherwise by its topic0 values if the
+// first topic is not a wildcard, otherwise it is checked for every log. A log is then only checked against the
+// subscriptions indexed by its address and by its first topic, and the unindexed ones.
+//
+// The keys are the raw bytes of the addresses and topics.
+type logIndex struct {
+	byAddress map[string]subscriptionSet
+	byTopic0  map[string]subscriptionSet
+	unindexed subscriptionSet
+}
+
+func newLogIndex() *logIndex {
+	return &logIndex{
+		byAddress: map[string]subscriptionSet{},
+		byTopic0:  map[string]subscriptionSet{},
+		unindexed: subscriptionSet{},
+	}
+}
+
+func (idx *logIndex) add(sub *EventSubscription) {
+	idx.update(sub, func(index map[string]subscriptionSet, key string) {
+		subs := index[key]
+		if subs == nil {
+			subs = subscriptionSet{}
+			index[key] = subs
+		}
+		subs[sub.GetId()] = sub
+	}, func() {
+		idx.unindexed[sub.GetId()] = sub
+	})
+}
+
+func (idx *logIndex) remove(sub *EventSubscription) {
+	idx.update(sub, func(index map[string]subscriptionSet, key string) {
+		delete(index[key], sub.GetId())
+		if len(index[key]) == 0 {
+			delete(index, key)
+		}
+	}, func() {
+		delete(idx.unindexed, sub.GetId())
+	})
+}
+
+// update calls indexed for each key the subscription is indexed by, or unindexed if it has none
+func (idx *logIndex) update(sub *EventSubscription, indexed func(map[string]subscriptionSet, string), unindexed func()) {
+	opts := sub.logOpts
+	switch {
+	case len(opts.Address) > 0:
+		for _, address := range opts.Address {
+			indexed(idx.byAddress, string(address.Bytes()))
+		}
+	case hasTopic0(opts.Topics):
+		for _, topic := range opts.Topics[0] {
+			indexed(idx.byTopic0, string(topic.Bytes()))
+		}
+	default:
+		unindexed()
+	}
+}
+
+// match returns the matching logs of each subscription, in the order of the given logs. The subscriptions without any
+// matching log are not included.
+func (idx *logIndex) match(logs event.Logs) map[*EventSubscription]event.Logs {
+	matched := map[*EventSubscription]event.Logs{}
+	collect := func(subs subscriptionSet, log *response.Log) {
+		for _, sub := range subs {
+			if matchLog(sub.logOpts, log) {
+				matched[sub] = append(matched[sub], log)
+			}
+		}
+	}
+	for _, log := range logs {
+		collect(idx.byAddress[string(log.Address.Bytes())], log)
+		if len(log.Topics) > 0 {
+			collect(idx.byTopic0[string(log.Topics[0].Bytes())], log)
+		}
+		collect(idx.unindexed, log)
+	}
+	return matched
+}
+
+// hasTopic0 returns true if the first topic of the subscription is restricted to a set of values
+func hasTopic0(topics request.Topics) bool {
+	if len(topics) == 0 || len(topics[0]) == 0 {
+		return false
+	}
+	for _, topic := range topics[0] {
+		// empty rule set == wildcard
+		if len(topic.Content) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchLog returns true if the log is from one of the addresses and has one of the topics at each position, an empty
+// list of addresses or topics, or an empty topic, matches anything
+func matchLog(opts request.LogSubscriptionOptions, log *response.Log) bool {
+	if len(opts.Address) > 0 {
+		found := false
+		for _, address := range opts.Address {
+			if bytes.Equal(address.Bytes(), log.Address.Bytes()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for i, sub := range opts.Topics {
+		match := len(sub) == 0 // empty rule set == wildcard
+		for _, topic := range sub {
+			if len(topic.Content) == 0 || (i < len(log.Topics) && bytes.Equal(log.Topics[i].Bytes(), topic.Bytes())) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
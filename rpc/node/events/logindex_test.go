@@ -0,0 +1,197 @@
+package events
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/aurora-is-near/relayer2-base/rpc"
+	"github.com/aurora-is-near/relayer2-base/types/common"
+	"github.com/aurora-is-near/relayer2-base/types/event"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/types/request"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// linearFilterLogs is the former filterLogs, which checks each log against a single subscription comparing the hex
+// addresses, it is the reference of the logIndex semantics
+func linearFilterLogs(logs event.Logs, opts request.LogSubscriptionOptions) event.Logs {
+	var ret event.Logs
+Logs:
+	for _, log := range logs {
+		if len(opts.Address) > 0 && !linearIncludes(opts.Address, log.Address.Hex()) {
+			continue
+		}
+		for i, sub := range opts.Topics {
+			match := len(sub) == 0 // empty rule set == wildcard
+			for _, topic := range sub {
+				if len(topic.Content) == 0 || (i < len(log.Topics) && bytes.Equal(log.Topics[i].Bytes(), topic.Bytes())) {
+					match = true
+					break
+				}
+			}
+			if !match {
+				continue Logs
+			}
+		}
+		ret = append(ret, log)
+	}
+	return ret
+}
+
+func linearIncludes(addresses []common.Address, address string) bool {
+	for _, addr := range addresses {
+		if strings.EqualFold(addr.Hex(), address) {
+			return true
+		}
+	}
+	return false
+}
+
+type logGenerator struct {
+	rnd       *rand.Rand
+	addresses []primitives.Data20
+	topics    []primitives.Data32
+}
+
+func newLogGenerator(seed int64, numAddresses, numTopics int) *logGenerator {
+	g := &logGenerator{rnd: rand.New(rand.NewSource(seed))}
+	for i := 0; i < numAddresses; i++ {
+		g.addresses = append(g.addresses, primitives.MustData20FromHex(fmt.Sprintf("0x%040x", i+1)))
+	}
+	for i := 0; i < numTopics; i++ {
+		g.topics = append(g.topics, primitives.MustData32FromHex(fmt.Sprintf("0x%064x", i+1)))
+	}
+	return g
+}
+
+func (g *logGenerator) log() *response.Log {
+	l := &response.Log{Address: g.addresses[g.rnd.Intn(len(g.addresses))]}
+	for i := g.rnd.Intn(5); i > 0; i-- {
+		l.Topics = append(l.Topics, g.topics[g.rnd.Intn(len(g.topics))])
+	}
+	return l
+}
+
+func (g *logGenerator) logs(n int) event.Logs {
+	logs := make(event.Logs, n)
+	for i := range logs {
+		logs[i] = g.log()
+	}
+	return logs
+}
+
+// subscription returns options with up to 2 addresses and up to 3 topic positions, each one holding up to 2 topics
+// among which an empty (wildcard) one
+func (g *logGenerator) subscription() *EventSubscription {
+	var opts request.LogSubscriptionOptions
+	for i := g.rnd.Intn(3); i > 0; i-- {
+		opts.Address = append(opts.Address, common.Address{Data20: g.addresses[g.rnd.Intn(len(g.addresses))]})
+	}
+	for i := g.rnd.Intn(4); i > 0; i-- {
+		var position []primitives.Data32
+		for j := g.rnd.Intn(3); j > 0; j-- {
+			if g.rnd.Intn(10) == 0 {
+				position = append(position, primitives.Data32{})
+			} else {
+				position = append(position, g.topics[g.rnd.Intn(len(g.topics))])
+			}
+		}
+		opts.Topics = append(opts.Topics, position)
+	}
+	return &EventSubscription{id: rpc.NewID(), typ: LogsSubscription, logOpts: opts}
+}
+
+func TestLogIndexMatchesLinearFilter(t *testing.T) {
+	g := newLogGenerator(1, 5, 5)
+	idx := newLogIndex()
+	subs := make([]*EventSubscription, 2000)
+	for i := range subs {
+		subs[i] = g.subscription()
+		idx.add(subs[i])
+	}
+	// the subscriptions are removed from all their buckets
+	for _, sub := range subs[1000:] {
+		idx.remove(sub)
+	}
+	subs = subs[:1000]
+
+	for i := 0; i < 50; i++ {
+		logs := g.logs(20)
+		matched := idx.match(logs)
+		numMatching := 0
+		for _, sub := range subs {
+			expected := linearFilterLogs(logs, sub.logOpts)
+			if len(expected) > 0 {
+				numMatching++
+			}
+			assert.Equal(t, expected, matched[sub], "subscription %+v", sub.logOpts)
+		}
+		assert.Len(t, matched, numMatching)
+	}
+}
+
+func TestLogIndexEmpty(t *testing.T) {
+	idx := newLogIndex()
+	sub := &EventSubscription{id: rpc.NewID(), logOpts: request.LogSubscriptionOptions{
+		Topics: request.Topics{{primitives.MustData32FromHex("0x1")}},
+	}}
+	idx.add(sub)
+	idx.remove(sub)
+	assert.Empty(t, idx.byAddress)
+	assert.Empty(t, idx.byTopic0)
+	assert.Empty(t, idx.unindexed)
+	assert.Empty(t, idx.match(event.Logs{{Topics: []primitives.Data32{primitives.MustData32FromHex("0x1")}}}))
+}
+
+// the subscriptions of a dApp usually filter a single contract, a few ones filter an event of all contracts
+func benchmarkSubscriptions(g *logGenerator, n int) []*EventSubscription {
+	subs := make([]*EventSubscription, n)
+	for i := range subs {
+		opts := request.LogSubscriptionOptions{}
+		if i%10 == 0 {
+			opts.Topics = request.Topics{{g.topics[g.rnd.Intn(len(g.topics))]}}
+		} else {
+			opts.Address = []common.Address{{Data20: g.addresses[g.rnd.Intn(len(g.addresses))]}}
+		}
+		subs[i] = &EventSubscription{id: rpc.NewID(), typ: LogsSubscription, logOpts: opts}
+	}
+	return subs
+}
+
+func BenchmarkLinearFilterLogs(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("subs=%d", n), func(b *testing.B) {
+			g := newLogGenerator(1, 5000, 500)
+			subs := benchmarkSubscriptions(g, n)
+			logs := g.logs(100)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, sub := range subs {
+					linearFilterLogs(logs, sub.logOpts)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLogIndexMatch(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("subs=%d", n), func(b *testing.B) {
+			g := newLogGenerator(1, 5000, 500)
+			idx := newLogIndex()
+			for _, sub := range benchmarkSubscriptions(g, n) {
+				idx.add(sub)
+			}
+			logs := g.logs(100)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				idx.match(logs)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package events
+
+import (
+	"github.com/aurora-is-near/relayer2-base/probe"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics holds the broker metrics registered through the probe registry, all methods are safe to call on a nil
+// metrics
+type metrics struct {
+	dropped     *prometheus.CounterVec
+	disconnects *prometheus.CounterVec
+}
+
+// newMetrics registers the broker metrics, returns nil if the probe is disabled or not started
+func newMetrics() *metrics {
+	dropped, ok := probe.SetCounterVec(probe.MetricConfig{
+		Id:         "events_dropped",
+		Name:       "events_dropped_total",
+		Help:       "Number of events dropped since the subscriber channel was full by subscription type",
+		LabelNames: []string{"type"},
+	})
+	if !ok {
+		return nil
+	}
+	disconnects, _ := probe.SetCounterVec(probe.MetricConfig{
+		Id:         "events_slow_consumer_disconnects",
+		Name:       "events_slow_consumer_disconnects_total",
+		Help:       "Number of subscriptions closed since the subscriber did not keep up by subscription type",
+		LabelNames: []string{"type"},
+	})
+	return &metrics{
+		dropped:     dropped,
+		disconnects: disconnects,
+	}
+}
+
+func (m *metrics) addDropped(typ Type) {
+	if m != nil {
+		m.dropped.WithLabelValues(typ.String()).Inc()
+	}
+}
+
+func (m *metrics) addDisconnect(typ Type) {
+	if m != nil {
+		m.disconnects.WithLabelValues(typ.String()).Inc()
+	}
+}
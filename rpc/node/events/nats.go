@@ -0,0 +1,164 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/aurora-is-near/relayer2-base/broker"
+	"github.com/aurora-is-near/relayer2-base/log"
+	"github.com/aurora-is-near/relayer2-base/types/event"
+	"github.com/aurora-is-near/relayer2-base/types/request"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	newHeadsSubject            = "newHeads"
+	logsSubject                = "logs"
+	pendingTransactionsSubject = "pendingTransactions"
+)
+
+// NatsBroker publishes the events to NATS subjects, so that the websocket clients of every relayer connected to the
+// same NATS server are notified of the blocks indexed by any of them. The events received from the subjects, including
+// the ones published by the relayer itself, are delivered to the local subscribers by an EventBroker.
+type NatsBroker struct {
+	Config NatsConfig
+	l      *log.Logger
+	local  *EventBroker
+	conn   *nats.Conn
+}
+
+// NewNatsBroker connects to the configured NATS server and subscribes to the event subjects, the local delivery
+// starts with Start
+func NewNatsBroker(config Config) (*NatsBroker, error) {
+	conn, err := nats.Connect(config.Nats.Url, nats.Name("relayer events"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS server at %s: %w", config.Nats.Url, err)
+	}
+	nb := &NatsBroker{
+		Config: config.Nats,
+		l:      log.Log(),
+		local:  NewEventBrokerWithConfig(config),
+		conn:   conn,
+	}
+	handlers := map[string]nats.MsgHandler{
+		newHeadsSubject: func(msg *nats.Msg) {
+			var b response.Block
+			if nb.decode(msg, &b) {
+				nb.local.PublishNewHeads(&b)
+			}
+		},
+		logsSubject: func(msg *nats.Msg) {
+			var l []*response.Log
+			if nb.decode(msg, &l) {
+				nb.local.PublishLogs(l)
+			}
+		},
+		pendingTransactionsSubject: func(msg *nats.Msg) {
+			var tx event.Transaction
+			if nb.decode(msg, &tx) {
+				nb.local.PublishPendingTransaction(tx)
+			}
+		},
+	}
+	for subject, handler := range handlers {
+		if _, err := conn.Subscribe(nb.subject(subject), handler); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to subscribe to NATS subject %s: %w", nb.subject(subject), err)
+		}
+	}
+	// the subscriptions are registered on the server before the first event is published
+	if err := conn.Flush(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe to NATS subjects: %w", err)
+	}
+	return nb, nil
+}
+
+// Start delivers the events received from NATS to the local subscribers, see EventBroker.Start
+func (nb *NatsBroker) Start() {
+	nb.local.Start()
+}
+
+// Stats returns the statistics of the local subscriptions, see EventBroker.Stats
+func (nb *NatsBroker) Stats() Stats {
+	return nb.local.Stats()
+}
+
+// Close closes the NATS connection after flushing the published events and then stops the local delivery
+func (nb *NatsBroker) Close() error {
+	err := nb.conn.Flush()
+	nb.conn.Close()
+	_ = nb.local.Close()
+	return err
+}
+
+// SubscribeNewHeads implements broker.Broker, see EventBroker.SubscribeNewHeads
+func (nb *NatsBroker) SubscribeNewHeads(ch chan event.Block) broker.Subscription {
+	return nb.local.SubscribeNewHeads(ch)
+}
+
+// SubscribeLogs implements broker.Broker, see EventBroker.SubscribeLogs
+func (nb *NatsBroker) SubscribeLogs(opts request.LogSubscriptionOptions, ch chan event.Logs) broker.Subscription {
+	return nb.local.SubscribeLogs(opts, ch)
+}
+
+// SubscribePendingTransactions implements broker.Broker, see EventBroker.SubscribePendingTransactions
+func (nb *NatsBroker) SubscribePendingTransactions(ch chan event.Transaction) broker.Subscription {
+	return nb.local.SubscribePendingTransactions(ch)
+}
+
+// UnsubscribeFromNewHeads implements broker.Broker, see EventBroker.UnsubscribeFromNewHeads
+func (nb *NatsBroker) UnsubscribeFromNewHeads(sub broker.Subscription) {
+	nb.local.UnsubscribeFromNewHeads(sub)
+}
+
+// UnsubscribeFromLogs implements broker.Broker, see EventBroker.UnsubscribeFromLogs
+func (nb *NatsBroker) UnsubscribeFromLogs(sub broker.Subscription) {
+	nb.local.UnsubscribeFromLogs(sub)
+}
+
+// UnsubscribeFromPendingTransactions implements broker.Broker, see EventBroker.UnsubscribeFromPendingTransactions
+func (nb *NatsBroker) UnsubscribeFromPendingTransactions(sub broker.Subscription) {
+	nb.local.UnsubscribeFromPendingTransactions(sub)
+}
+
+// PublishNewHeads publishes the block to the NATS subject. Implements broker.Broker interface
+func (nb *NatsBroker) PublishNewHeads(b event.Block) {
+	nb.publish(newHeadsSubject, b)
+}
+
+// PublishLogs publishes the logs to the NATS subject, every relayer filters them for its own subscribers. Implements
+// broker.Broker interface
+func (nb *NatsBroker) PublishLogs(l event.Logs) {
+	nb.publish(logsSubject, l)
+}
+
+// PublishPendingTransaction publishes the transaction to the NATS subject. Implements broker.Broker interface
+func (nb *NatsBroker) PublishPendingTransaction(tx event.Transaction) {
+	nb.publish(pendingTransactionsSubject, tx)
+}
+
+func (nb *NatsBroker) publish(subject string, ev any) {
+	data, err := jsoniter.Marshal(ev)
+	if err != nil {
+		nb.l.Error().Err(err).Msgf("failed to encode event for NATS subject %s", nb.subject(subject))
+		return
+	}
+	if err := nb.conn.Publish(nb.subject(subject), data); err != nil {
+		nb.l.Error().Err(err).Msgf("failed to publish event to NATS subject %s", nb.subject(subject))
+	}
+}
+
+func (nb *NatsBroker) decode(msg *nats.Msg, ev any) bool {
+	if err := jsoniter.Unmarshal(msg.Data, ev); err != nil {
+		nb.l.Warn().Err(err).Msgf("failed to decode event received from NATS subject %s", msg.Subject)
+		return false
+	}
+	return true
+}
+
+func (nb *NatsBroker) subject(name string) string {
+	return nb.Config.SubjectPrefix + "." + name
+}
@@ -0,0 +1,103 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/rpc/node/events"
+	"github.com/aurora-is-near/relayer2-base/types/common"
+	"github.com/aurora-is-near/relayer2-base/types/event"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/types/request"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startNatsServer(t *testing.T) string {
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	require.NoError(t, err)
+	go srv.Start()
+	require.True(t, srv.ReadyForConnections(5*time.Second))
+	t.Cleanup(srv.Shutdown)
+	return srv.ClientURL()
+}
+
+func startNatsBroker(t *testing.T, url string, subjectPrefix string) *events.NatsBroker {
+	config := events.DefaultConfig()
+	config.Backend = events.NatsBackend
+	config.Nats = events.NatsConfig{Url: url, SubjectPrefix: subjectPrefix}
+	nb, err := events.NewNatsBroker(config)
+	require.NoError(t, err)
+	go nb.Start()
+	t.Cleanup(func() { _ = nb.Close() })
+	return nb
+}
+
+func TestNatsBroker(t *testing.T) {
+	url := startNatsServer(t)
+	// two replicas of the same network and a relayer of another network sharing the NATS server
+	indexer := startNatsBroker(t, url, "test.mainnet")
+	replica := startNatsBroker(t, url, "test.mainnet")
+	other := startNatsBroker(t, url, "test.testnet")
+
+	address := primitives.MustData20FromHex("0x0000000000000000000000000000000000000001")
+	ownHeads := make(chan event.Block, 1)
+	indexer.SubscribeNewHeads(ownHeads)
+	heads := make(chan event.Block, 1)
+	replica.SubscribeNewHeads(heads)
+	logs := make(chan event.Logs, 1)
+	replica.SubscribeLogs(request.LogSubscriptionOptions{Address: []common.Address{{Data20: address}}}, logs)
+	otherHeads := make(chan event.Block, 1)
+	other.SubscribeNewHeads(otherHeads)
+	pending := make(chan event.Transaction, 1)
+	replica.SubscribePendingTransactions(pending)
+
+	indexer.PublishNewHeads(&response.Block{Number: 42, Hash: primitives.MustData32FromHex("0x2a")})
+	indexer.PublishLogs(event.Logs{
+		{Address: primitives.MustData20FromHex("0x0000000000000000000000000000000000000002"), LogIndex: 0},
+		{Address: address, LogIndex: 1, Removed: true},
+	})
+	indexer.PublishPendingTransaction(event.Transaction{ChainId: 1313161554, Hash: primitives.MustData32FromHex("0x2b")})
+
+	for _, ch := range []chan event.Block{ownHeads, heads} {
+		select {
+		case b := <-ch:
+			assert.Equal(t, primitives.HexUint(42), b.Number)
+			assert.Equal(t, primitives.MustData32FromHex("0x2a"), b.Hash)
+		case <-time.After(2 * time.Second):
+			t.Fatal("head is not received")
+		}
+	}
+	select {
+	case ls := <-logs:
+		require.Len(t, ls, 1, "logs are filtered by every replica")
+		assert.Equal(t, primitives.HexUint(1), ls[0].LogIndex)
+		assert.True(t, ls[0].Removed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("logs are not received")
+	}
+	select {
+	case tx := <-pending:
+		assert.Equal(t, uint64(1313161554), tx.ChainId)
+		assert.Equal(t, primitives.MustData32FromHex("0x2b"), tx.Hash)
+		assert.Nil(t, tx.Tx, "transaction details are not made up")
+	case <-time.After(2 * time.Second):
+		t.Fatal("pending transaction is not received")
+	}
+	select {
+	case <-otherHeads:
+		t.Fatal("head of another network is received")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Equal(t, 1, replica.Stats().LogsSubscriptions)
+}
+
+func TestNatsBrokerUnreachable(t *testing.T) {
+	config := events.DefaultConfig()
+	config.Nats.Url = "nats://127.0.0.1:1"
+	_, err := events.NewNatsBroker(config)
+	assert.Error(t, err)
+}
@@ -3,7 +3,9 @@ package node
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/aurora-is-near/relayer2-base/broker"
 	"github.com/aurora-is-near/relayer2-base/log"
@@ -13,7 +15,8 @@ import (
 
 type RpcNode struct {
 	rpc.RpcServer
-	Broker broker.Broker
+	Broker  broker.Broker
+	limiter *rpc.RateLimiter
 }
 
 func New() (*RpcNode, error) {
@@ -67,22 +70,85 @@ func NewWithConf(config *Config) (*RpcNode, error) {
 		}
 		transports = append(transports, rpc.WithTransport(&rpc.HttpServer{Config: httpCfg, Logger: logger}))
 	}
+	// If ipcPath is not empty, then an IpcServer should be initialized
+	if config.IpcPath != "" {
+		transports = append(transports, rpc.WithTransport(&rpc.IpcServer{Config: rpc.IpcConfig{Path: config.IpcPath},
+			Logger: logger}))
+	}
 	if len(transports) == 0 {
 		logger.Fatal().Msg("rpc server configuration error, no transport configured")
 	}
 
 	srv := rpc.New(logger, config.MaxBatchRequests, transports...)
-	node := &RpcNode{RpcServer: *srv}
+	node := &RpcNode{RpcServer: *srv, limiter: rpc.NewRateLimiter(config.RateLimit)}
+	node.WithShutdownTimeout(config.ShutdownTimeout * time.Second)
+	// the limiter is always in the chain so that it can be enabled by a config change without a restart
+	node.WithMiddleware(node.limiter.Middleware)
+	if config.EnableMetrics {
+		if metrics := rpc.NewMetrics(); metrics != nil {
+			node.WithMetrics(metrics)
+		} else {
+			logger.Warn().Msg("rpc metrics are enabled but probe is not started, metrics will not be recorded")
+		}
+	}
 
-	// Start eventbroker if WS configured
-	if config.wsEndpoint() != "" {
-		eb := events.NewEventBroker()
-		go eb.Start()
-		node.Broker = eb
+	// Start eventbroker if WS or IPC configured
+	if config.wsEndpoint() != "" || config.IpcPath != "" {
+		switch config.Events.Backend {
+		case events.NatsBackend:
+			nb, err := events.NewNatsBroker(config.Events)
+			if err != nil {
+				return nil, err
+			}
+			go nb.Start()
+			node.Broker = nb
+		default:
+			if config.Events.Backend != events.LocalBackend {
+				logger.Warn().Msgf("unknown events backend [%s], falling back to %s", config.Events.Backend,
+					events.LocalBackend)
+			}
+			eb := events.NewEventBrokerWithConfig(config.Events)
+			go eb.Start()
+			node.Broker = eb
+		}
 	}
 	return node, nil
 }
 
+// HandleConfigChange reloads the rate limits from the configuration. It is not registered by the node itself since
+// viper keeps a single config change handler, the embedder must call it from its own handler, e.g.
+//
+//	viper.OnConfigChange(func(fsnotify.Event) {
+//		logger.HandleConfigChange()
+//		rpcNode.HandleConfigChange()
+//	})
+//
+// otherwise the rate limits are only read once by NewWithConf.
+func (n *RpcNode) HandleConfigChange() {
+	n.limiter.SetConfig(GetConfig().RateLimit)
+}
+
+// Shutdown stops the RPC server gracefully, see rpc.RpcServer.Close, then closes the broker and finally the given
+// closers in order, typically the DB handler, which are not used by any request anymore
+func (n *RpcNode) Shutdown(closers ...io.Closer) error {
+	n.Close()
+	var errs []error
+	if c, ok := n.Broker.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close broker: %w", err))
+		}
+	}
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown failed: %v", errs)
+	}
+	return nil
+}
+
 // Start starts RPC server as a seperate go routine
 func (n *RpcNode) Start() {
 	go func() {
@@ -0,0 +1,158 @@
+package rpc
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	errs "github.com/aurora-is-near/relayer2-base/types/errors"
+	"github.com/aurora-is-near/relayer2-base/utils"
+)
+
+// RateLimitConfig holds the token bucket budgets of the rate limiter. Rates are in tokens per second and bursts are
+// the bucket capacities, a call consumes as many tokens as its method weight (1 if not listed) from both the client
+// IP bucket and the client IP + method bucket.
+type RateLimitConfig struct {
+	Enabled            bool               `mapstructure:"enabled"`
+	IpRate             float64            `mapstructure:"ipRate"`
+	IpBurst            float64            `mapstructure:"ipBurst"`
+	MethodRate         float64            `mapstructure:"methodRate"`
+	MethodBurst        float64            `mapstructure:"methodBurst"`
+	MethodWeights      map[string]float64 `mapstructure:"methodWeights"`
+	IdleTimeoutSeconds uint               `mapstructure:"idleTimeoutSeconds"`
+}
+
+// weight returns the number of tokens a call to the given method consumes, method names are matched case
+// insensitively since viper lowercases the map keys
+func (c *RateLimitConfig) weight(method string) float64 {
+	if w, ok := c.MethodWeights[strings.ToLower(method)]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens accumulated since the last refill, capped at burst
+func (b *bucket) refill(rate, burst float64, now time.Time) {
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+}
+
+// wait returns how long it takes for the bucket to hold the given number of tokens
+func (b *bucket) wait(cost, rate float64) time.Duration {
+	if b.tokens >= cost {
+		return 0
+	}
+	if rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration((cost - b.tokens) / rate * float64(time.Second))
+}
+
+// RateLimiter is a token bucket rate limiter keyed by client IP and by client IP + method. The limits can be replaced
+// at runtime with SetConfig, existing buckets keep their tokens and are capped by the new bursts on their next use.
+type RateLimiter struct {
+	mu        sync.Mutex
+	config    RateLimitConfig
+	ipBuckets map[string]*bucket
+	mBuckets  map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		config:    config,
+		ipBuckets: map[string]*bucket{},
+		mBuckets:  map[string]*bucket{},
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// SetConfig replaces the limits of the rate limiter
+func (l *RateLimiter) SetConfig(config RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = config
+}
+
+// Allow consumes the weight of the method from the budgets of the client and returns the time to wait before
+// retrying if any of the budgets is exhausted, in which case nothing is consumed
+func (l *RateLimiter) Allow(clientIp string, method string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.config.Enabled {
+		return true, 0
+	}
+	now := l.now()
+	l.sweep(now)
+
+	cost := l.config.weight(method)
+	ipBucket := l.bucket(l.ipBuckets, clientIp, l.config.IpRate, l.config.IpBurst, now)
+	mBucket := l.bucket(l.mBuckets, clientIp+"/"+strings.ToLower(method), l.config.MethodRate, l.config.MethodBurst, now)
+
+	// heavier calls than the bucket capacity would never pass, let them drain the full bucket instead
+	ipCost := math.Min(cost, l.config.IpBurst)
+	mCost := math.Min(cost, l.config.MethodBurst)
+	wait := ipBucket.wait(ipCost, l.config.IpRate)
+	if w := mBucket.wait(mCost, l.config.MethodRate); w > wait {
+		wait = w
+	}
+	if wait > 0 {
+		return false, wait
+	}
+	ipBucket.tokens -= ipCost
+	mBucket.tokens -= mCost
+	return true, 0
+}
+
+// bucket returns the refilled bucket of the given key, new buckets start full
+func (l *RateLimiter) bucket(buckets map[string]*bucket, key string, rate, burst float64, now time.Time) *bucket {
+	b, ok := buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		buckets[key] = b
+		return b
+	}
+	b.refill(rate, burst, now)
+	return b
+}
+
+// sweep drops the buckets that were not used for the configured idle timeout, they would be full again anyway
+func (l *RateLimiter) sweep(now time.Time) {
+	idle := time.Duration(l.config.IdleTimeoutSeconds) * time.Second
+	if idle <= 0 || now.Sub(l.lastSweep) < idle {
+		return
+	}
+	l.lastSweep = now
+	for _, buckets := range []map[string]*bucket{l.ipBuckets, l.mBuckets} {
+		for k, b := range buckets {
+			if now.Sub(b.last) >= idle {
+				delete(buckets, k)
+			}
+		}
+	}
+}
+
+// Middleware rejects the calls of the clients exceeding their budgets with errs.RateLimitError. Batch children pass
+// through the middleware one by one, so each of them is counted separately.
+func (l *RateLimiter) Middleware(next RpcHandler) RpcHandler {
+	return func(ctx *context.Context, rpcCtx *RpcContext) *RpcContext {
+		clientIp, ok := utils.ClientIpFromContext(*ctx)
+		if !ok || rpcCtx.parsedBody == nil {
+			return next(ctx, rpcCtx)
+		}
+		if ok, retryAfter := l.Allow(clientIp.String(), rpcCtx.parsedBody.Method.Str()); !ok {
+			return rpcCtx.SetErrorObject(&errs.RateLimitError{RetryAfter: retryAfter})
+		}
+		return next(ctx, rpcCtx)
+	}
+}
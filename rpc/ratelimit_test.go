@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/rpc/types"
+	"github.com/aurora-is-near/relayer2-base/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(now *time.Time) *RateLimiter {
+	l := NewRateLimiter(RateLimitConfig{
+		Enabled:       true,
+		IpRate:        10,
+		IpBurst:       10,
+		MethodRate:    5,
+		MethodBurst:   5,
+		MethodWeights: map[string]float64{"eth_getlogs": 4},
+	})
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestRateLimiterBudgets(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newTestLimiter(&now)
+
+	for i := 0; i < 5; i++ {
+		ok, _ := l.Allow("1.1.1.1", "eth_chainId")
+		require.True(t, ok)
+	}
+	ok, retryAfter := l.Allow("1.1.1.1", "eth_chainId")
+	assert.False(t, ok, "method budget must be exhausted")
+	assert.Equal(t, 200*time.Millisecond, retryAfter)
+
+	ok, _ = l.Allow("1.1.1.1", "eth_getLogs")
+	assert.True(t, ok, "other methods have their own budget")
+	ok, retryAfter = l.Allow("1.1.1.1", "eth_getLogs")
+	assert.False(t, ok, "heavy calls must consume their weight")
+	assert.Equal(t, 600*time.Millisecond, retryAfter)
+
+	ok, _ = l.Allow("2.2.2.2", "eth_getLogs")
+	assert.True(t, ok, "other clients have their own budget")
+
+	now = now.Add(time.Second)
+	ok, _ = l.Allow("1.1.1.1", "eth_getLogs")
+	assert.True(t, ok, "budgets must be refilled")
+
+	l.SetConfig(RateLimitConfig{Enabled: false})
+	for i := 0; i < 100; i++ {
+		ok, _ = l.Allow("1.1.1.1", "eth_getLogs")
+		require.True(t, ok)
+	}
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newTestLimiter(&now)
+	handler := l.Middleware(func(ctx *context.Context, rpcCtx *RpcContext) *RpcContext {
+		return rpcCtx.setResult([]byte("true"))
+	})
+
+	ctx := utils.PutClientIpKey(context.Background(), net.ParseIP("1.1.1.1"))
+	call := func() string {
+		body, err := types.ParseRPCRequestBody([]byte(`{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[]}`))
+		require.NoError(t, err)
+		return string(handler(&ctx, &RpcContext{parsedBody: body}).response)
+	}
+
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":true}`, call())
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32901,"message":"rate limit exceeded, retry after 600ms","data":"1"}}`, call())
+}
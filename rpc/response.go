@@ -3,6 +3,8 @@ package rpc
 import (
 	"bytes"
 	"fmt"
+
+	jsoniter "github.com/json-iterator/go"
 )
 
 func createResponse(idRepr []byte, resultRepr []byte) []byte {
@@ -22,14 +24,36 @@ func createEventResponse(subscription, resultRepr []byte) []byte {
 	return buf.Bytes()
 }
 
+func createEventErrorResponse(subscription []byte, code int64, message string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(
+		&buf,
+		`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"%s","error":{"code":%d,"message":%s}}}`,
+		subscription,
+		code,
+		jsonString(message),
+	)
+	return buf.Bytes()
+}
+
 func createErrorResponse(idRepr []byte, code int64, message string) []byte {
 	var buf bytes.Buffer
-	fmt.Fprintf(&buf, `{"jsonrpc":"2.0","id":%s,"error":{"code":%d,"message":"%s"}}`, idRepr, code, message)
+	fmt.Fprintf(&buf, `{"jsonrpc":"2.0","id":%s,"error":{"code":%d,"message":%s}}`, idRepr, code, jsonString(message))
 	return buf.Bytes()
 }
 
 func createDataErrorResponse(idRepr []byte, code int64, message string, data string) []byte {
 	var buf bytes.Buffer
-	fmt.Fprintf(&buf, `{"jsonrpc":"2.0","id":%s,"error":{"code":%d, "data": "%s", "message":"%s"}}`, idRepr, code, data, message)
+	fmt.Fprintf(&buf, `{"jsonrpc":"2.0","id":%s,"error":{"code":%d, "data": %s, "message":%s}}`, idRepr, code, jsonString(data), jsonString(message))
 	return buf.Bytes()
 }
+
+// jsonString returns the quoted and escaped JSON representation of s, messages may come from upstreams or
+// user input so they can not be placed in the responses as is
+func jsonString(s string) []byte {
+	b, err := jsoniter.Marshal(s)
+	if err != nil {
+		return []byte(`""`)
+	}
+	return b
+}
@@ -14,6 +14,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/aurora-is-near/relayer2-base/log"
 	errs "github.com/aurora-is-near/relayer2-base/types/errors"
@@ -23,25 +24,35 @@ import (
 )
 
 const (
-	subscriptionPrefix = "eth_"
+	subscriptionPrefix     = "eth_"
+	defaultShutdownTimeout = 10 * time.Second
 )
 
 type RpcServer struct {
 	serviceMap       ServiceMap
 	logger           *log.Logger
 	middlewares      []Middleware
+	metrics          *Metrics
 	transports       []Transport
 	mu               sync.RWMutex
 	maxBatchRequests uint
+	shutdownTimeout  time.Duration
+	// requestsCtx is canceled once Close stops waiting for the in-flight requests
+	requestsCtx    context.Context
+	cancelRequests context.CancelFunc
 }
 
 func New(l *log.Logger, maxBatchReq uint, transports ...TransportOption) *RpcServer {
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
 	s := &RpcServer{
 		serviceMap:       ServiceMap{},
 		logger:           l,
 		transports:       []Transport{},
 		mu:               sync.RWMutex{},
 		maxBatchRequests: maxBatchReq,
+		shutdownTimeout:  defaultShutdownTimeout,
+		requestsCtx:      requestsCtx,
+		cancelRequests:   cancelRequests,
 	}
 	for _, transport := range transports {
 		transport(s)
@@ -92,6 +103,8 @@ func (r *RpcServer) Run(ctx context.Context) error {
 }
 
 func (r *RpcServer) ResolveHttp(ctx *context.Context, rpcMessage []byte) []byte {
+	ctx, cancel := r.requestContext(ctx)
+	defer cancel()
 	rpcCtx := r.prepareRpcContext(rpcMessage, nil)
 
 	if rpcCtx.hasParseError() {
@@ -104,6 +117,8 @@ func (r *RpcServer) ResolveHttp(ctx *context.Context, rpcMessage []byte) []byte
 }
 
 func (r *RpcServer) ResolveWs(ctx *context.Context, wsCtx *WebSocketContext, rpcMessage []byte) []byte {
+	ctx, cancel := r.requestContext(ctx)
+	defer cancel()
 	rpcCtx := r.prepareRpcContext(rpcMessage, wsCtx)
 
 	if rpcCtx.hasParseError() {
@@ -115,9 +130,17 @@ func (r *RpcServer) ResolveWs(ctx *context.Context, wsCtx *WebSocketContext, rpc
 	}
 }
 
+// OpenWsConn counts the websocket connection in the metrics of the server, implements WsConnOpener
+func (r *RpcServer) OpenWsConn(wsCtx *WebSocketContext) {
+	wsCtx.metrics = r.metrics
+	wsCtx.metrics.addWsConnections(1)
+}
+
 func (r *RpcServer) CloseWsConn(wsCtx *WebSocketContext) {
 	wsCtx.subscriptionsMtx.Lock()
 	defer wsCtx.subscriptionsMtx.Unlock()
+	wsCtx.metrics.addWsConnections(-1)
+	wsCtx.metrics.addSubscriptions(-len(wsCtx.subscriptions))
 	// WS connection is closing, if all subscriptions are prevously unsubscribed subsciptions list is empty
 	// if it is not empty, notify err channel of each subscription, so that the records can be cleaned
 	//  from broker notify list
@@ -131,6 +154,7 @@ func (r *RpcServer) CloseWsConn(wsCtx *WebSocketContext) {
 
 // executeBatchRequest runs each json-rpc request in parallel and formats the responses and returns updated RpcContext object with the total response
 func (r *RpcServer) executeBatchRequest(ctx *context.Context, rpcCtx *RpcContext, isWs bool) *RpcContext {
+	r.metrics.observeBatch(len(rpcCtx.batchChildren))
 	childResponsesChan := make(chan *RpcContext)
 
 	for i, child := range rpcCtx.batchChildren {
@@ -231,7 +255,7 @@ func (r *RpcServer) callSubscribe(ctx *context.Context, rpcCtx *RpcContext) *Rpc
 	args = args[1:]
 
 	// Add notifier to context so that subscription handler can use it
-	n := &Notifier{h: handler, wsCtx: rpcCtx.wsCtx}
+	n := newNotifier(handler, rpcCtx.wsCtx)
 	*ctx = PutNotifierKey(*ctx, n)
 	resp, err := handler.call(ctx, args)
 	if err != nil {
@@ -269,6 +293,7 @@ func (r *RpcServer) callUnsubscribe(ctx *context.Context, rpcCtx *RpcContext) *R
 	close(sub.err)
 	// delete the related subscription from subscriptions list
 	delete(rpcCtx.wsCtx.subscriptions, ID(subscriptionId))
+	rpcCtx.wsCtx.metrics.addSubscriptions(-1)
 
 	return rpcCtx.setResult([]byte("true"))
 }
@@ -287,7 +312,8 @@ func (r *RpcServer) callMethod(ctx *context.Context, rpcCtx *RpcContext) *RpcCon
 		return rpcCtx.SetErrorObject(&errs.InvalidParamsError{Message: err.Error()})
 	}
 
-	resp, err := s.handler.call(ctx, args)
+	hCtx := PutRawParams(*ctx, rpcCtx.parsedBody.Params.Value)
+	resp, err := s.handler.call(&hCtx, args)
 	if err != nil {
 		e, ok := err.(errs.Error)
 		if ok {
@@ -305,11 +331,54 @@ func (r *RpcServer) callMethod(ctx *context.Context, rpcCtx *RpcContext) *RpcCon
 	return rpcCtx.setResult(respJson)
 }
 
-// Close gracefully stops RpcServer
+// WithShutdownTimeout sets how long Close waits for the in-flight requests before canceling their contexts
+func (r *RpcServer) WithShutdownTimeout(timeout time.Duration) {
+	r.shutdownTimeout = timeout
+}
+
+// Close stops the transports from accepting requests and waits up to the shutdown timeout for the in-flight ones, the
+// contexts of the requests still running are then canceled, e.g. to stop the long DB scans.
 func (r *RpcServer) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.shutdownTimeout)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		r.cancelRequests()
+	}()
+
+	var wg sync.WaitGroup
 	for _, t := range r.transports {
-		t.Stop()
+		wg.Add(1)
+		go func(t Transport) {
+			defer wg.Done()
+			var err error
+			if gt, ok := t.(GracefulTransport); ok {
+				err = gt.Shutdown(ctx)
+			} else {
+				err = t.Stop()
+			}
+			if err != nil {
+				r.logger.Warn().Err(err).Msg("transport is not stopped gracefully")
+			}
+		}(t)
 	}
+	wg.Wait()
+}
+
+// requestContext returns the context of a request, which is canceled once either ctx is done or Close stops waiting
+// for the in-flight requests. The returned cancel function must be called once the request is served.
+func (r *RpcServer) requestContext(ctx *context.Context) (*context.Context, context.CancelFunc) {
+	reqCtx, cancel := context.WithCancel(*ctx)
+	// the returned context may be replaced through the pointer, so the channel is taken before the goroutine starts
+	done := reqCtx.Done()
+	go func() {
+		select {
+		case <-r.requestsCtx.Done():
+			cancel()
+		case <-done:
+		}
+	}()
+	return &reqCtx, cancel
 }
 
 // prepareArguments tries to parse the given args to an array of values with the
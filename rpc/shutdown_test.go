@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/log"
+	"github.com/fasthttp/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type slowService struct {
+	canceled chan error
+}
+
+// Sleep returns after the given number of milliseconds, or once the request is canceled
+func (s *slowService) Sleep(ctx context.Context, ms int) (string, error) {
+	select {
+	case <-time.After(time.Duration(ms) * time.Millisecond):
+		return "done", nil
+	case <-ctx.Done():
+		s.canceled <- ctx.Err()
+		return "", ctx.Err()
+	}
+}
+
+func startShutdownServer(t *testing.T, timeout time.Duration) (*RpcServer, *slowService, string) {
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	srv := New(log.Log(), 10, WithTransport(&HttpServer{
+		Logger: log.Log(),
+		Config: HttpConfig{
+			HttpEndpoint:       addr,
+			HttpPathPrefix:     "*",
+			HttpTimeout:        10,
+			WsEndpoint:         addr,
+			WsPathPrefix:       "*",
+			WsHandshakeTimeout: 10,
+		},
+	}))
+	srv.WithShutdownTimeout(timeout)
+	service := &slowService{canceled: make(chan error, 1)}
+	require.NoError(t, srv.RegisterEndpoints("test", service))
+	require.NoError(t, srv.Run(context.Background()))
+	return srv, service, addr
+}
+
+func sleepRequest(addr string, ms string) (string, error) {
+	body := `{"jsonrpc":"2.0","id":1,"method":"test_sleep","params":[` + ms + `]}`
+	resp, err := http.Post("http://"+addr, DefaultContentType, strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+func TestHttpServerShutdownDrainsRequests(t *testing.T) {
+	srv, _, addr := startShutdownServer(t, 5*time.Second)
+	require.Eventually(t, func() bool {
+		_, err := sleepRequest(addr, "0")
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws://"+addr, nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	type result struct {
+		body string
+		err  error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		body, err := sleepRequest(addr, "300")
+		inFlight <- result{body, err}
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		srv.Close()
+		close(closed)
+	}()
+	res := <-inFlight
+	require.NoError(t, res.err)
+	assert.Contains(t, res.body, `"result":"done"`)
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, _, err = ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "unexpected error %v", err)
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server is not closed")
+	}
+	_, err = sleepRequest(addr, "0")
+	assert.Error(t, err, "new requests are refused")
+}
+
+func TestHttpServerShutdownCancelsRequests(t *testing.T) {
+	srv, service, addr := startShutdownServer(t, 100*time.Millisecond)
+	require.Eventually(t, func() bool {
+		_, err := sleepRequest(addr, "0")
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+
+	go func() {
+		_, _ = sleepRequest(addr, "10000")
+	}()
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	srv.Close()
+	select {
+	case err := <-service.canceled:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("request is not canceled")
+	}
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestRequestContextKeepsIncomingCancellation(t *testing.T) {
+	srv := New(log.Log(), 10)
+	service := &slowService{canceled: make(chan error, 1)}
+	require.NoError(t, srv.RegisterEndpoints("test", service))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go srv.ResolveHttp(&ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_sleep","params":[10000]}`))
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-service.canceled:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("request is not canceled with the incoming context")
+	}
+}
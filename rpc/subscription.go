@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	errs "github.com/aurora-is-near/relayer2-base/types/errors"
 	jsoniter "github.com/json-iterator/go"
 )
 
@@ -91,6 +92,20 @@ type Notifier struct {
 
 	mu  sync.Mutex
 	sub *Subscription
+	// notifications are buffered until the subscribe response is queued, see WebSocketContext.respond
+	activated bool
+	buffer    []jsoniter.RawMessage
+	// closing is the error notification of a subscription closed before activation, sent after the buffered ones
+	closing []byte
+}
+
+// newNotifier creates a notifier which is activated once the response of the request being served is queued
+func newNotifier(h *handler, wsCtx *WebSocketContext) *Notifier {
+	n := &Notifier{h: h, wsCtx: wsCtx}
+	wsCtx.subscriptionsMtx.Lock()
+	defer wsCtx.subscriptionsMtx.Unlock()
+	wsCtx.inactiveNotifiers = append(wsCtx.inactiveNotifiers, n)
+	return n
 }
 
 // CreateSubscription returns a new subscription that is coupled to the RPC connection
@@ -102,7 +117,10 @@ func (n *Notifier) CreateSubscription() *Subscription {
 		panic("can't create multiple subscriptions with Notifier")
 	}
 	n.sub = &Subscription{ID: globalGen(), method: n.method, err: make(chan error, 1)}
+	n.wsCtx.subscriptionsMtx.Lock()
+	defer n.wsCtx.subscriptionsMtx.Unlock()
 	n.wsCtx.subscriptions[n.sub.ID] = n.sub
+	n.wsCtx.metrics.addSubscriptions(1)
 	return n.sub
 }
 
@@ -122,14 +140,72 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 		return errors.New("Notify with wrong ID")
 	}
 
+	if !n.activated {
+		n.buffer = append(n.buffer, enc)
+		return nil
+	}
 	return n.send(n.sub, enc)
 }
 
+// Close sends the given error as the last notification of the subscription and closes it, as if the client
+// unsubscribed. It is a no-op if the subscription is already closed.
+func (n *Notifier) Close(id ID, err error) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.sub == nil {
+		return errors.New("can't Close since subscription is nil")
+	} else if n.sub.ID != id {
+		return errors.New("Close with wrong ID")
+	}
+
+	n.wsCtx.subscriptionsMtx.Lock()
+	if _, ok := n.wsCtx.subscriptions[id]; !ok {
+		n.wsCtx.subscriptionsMtx.Unlock()
+		return nil
+	}
+	close(n.sub.err)
+	delete(n.wsCtx.subscriptions, id)
+	n.wsCtx.subscriptionsMtx.Unlock()
+	n.wsCtx.metrics.addSubscriptions(-1)
+
+	code := int64(errs.Generic)
+	if e, ok := err.(errs.Error); ok {
+		code = int64(e.ErrorCode())
+	}
+	resp := createEventErrorResponse([]byte(id), code, err.Error())
+	if !n.activated {
+		// the client has not received the subscription id yet, the error is sent once it does
+		n.closing = resp
+		return nil
+	}
+	n.wsCtx.send(resp)
+	return nil
+}
+
+// activate sends the buffered notifications, the next ones are sent right away
+func (n *Notifier) activate() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.activated = true
+	if n.sub != nil {
+		for _, enc := range n.buffer {
+			_ = n.send(n.sub, enc)
+		}
+	}
+	if n.closing != nil {
+		n.wsCtx.send(n.closing)
+	}
+	n.buffer = nil
+	n.closing = nil
+}
+
 // send generates the response and writes is to the websocket connection's output channel
 func (n *Notifier) send(sub *Subscription, data jsoniter.RawMessage) error {
 	resp := createEventResponse([]byte(sub.ID), data)
-	if resp != nil && !n.wsCtx.closed.Load() {
-		n.wsCtx.output <- resp
+	if resp != nil {
+		n.wsCtx.send(resp)
 	}
 
 	return nil
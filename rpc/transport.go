@@ -17,8 +17,28 @@ type Transport interface {
 	Stop() error
 }
 
+// GracefulTransport is a Transport which can stop accepting requests while serving the in-flight ones
+type GracefulTransport interface {
+	Transport
+	// Shutdown stops accepting requests and returns once the in-flight ones are served, or ctx is done
+	Shutdown(ctx context.Context) error
+}
+
 type Resolver interface {
 	ResolveHttp(ctx *context.Context, rpcMessage []byte) []byte
 	ResolveWs(ctx *context.Context, wsCtx *WebSocketContext, rpcMessage []byte) []byte
 	CloseWsConn(wsCtx *WebSocketContext)
 }
+
+// WsConnOpener is optionally implemented by a Resolver to be told about the new websocket connections, e.g. to count
+// them, see RpcServer.OpenWsConn
+type WsConnOpener interface {
+	OpenWsConn(wsCtx *WebSocketContext)
+}
+
+// openWsConn tells the resolver about the new websocket connection if it implements WsConnOpener
+func openWsConn(resolver Resolver, wsCtx *WebSocketContext) {
+	if o, ok := resolver.(WsConnOpener); ok {
+		o.OpenWsConn(wsCtx)
+	}
+}
@@ -0,0 +1,46 @@
+package txpool
+
+import (
+	"github.com/aurora-is-near/relayer2-base/cmdutils"
+	"github.com/aurora-is-near/relayer2-base/log"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultMaxSize          = 10000
+	defaultMaxAgeMinutes    = 30
+	defaultFilterTtlMinutes = 15
+
+	configPath = "txpool"
+)
+
+type Config struct {
+	// MaxSize caps the number of pending transactions of each chain, the oldest ones are evicted first
+	MaxSize int `mapstructure:"maxSize"`
+	// MaxAgeMinutes evicts the transactions which are not included in a block within the given time, i.e. rejected
+	// ones, no limit if not positive
+	MaxAgeMinutes    int `mapstructure:"maxAgeMinutes"`
+	FilterTtlMinutes int `mapstructure:"filterTtlMinutes"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		MaxSize:          defaultMaxSize,
+		MaxAgeMinutes:    defaultMaxAgeMinutes,
+		FilterTtlMinutes: defaultFilterTtlMinutes,
+	}
+}
+
+func GetConfig() *Config {
+	config := defaultConfig()
+	sub := viper.Sub(configPath)
+	if sub != nil {
+		cmdutils.BindSubViper(sub, configPath)
+		if err := sub.Unmarshal(&config); err != nil {
+			log.Log().Warn().Err(err).Msgf("failed to parse configuration [%s] from [%s], "+
+				"falling back to defaults", configPath, viper.ConfigFileUsed())
+		}
+	}
+	return config
+}
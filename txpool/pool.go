@@ -0,0 +1,278 @@
+package txpool
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/broker"
+	"github.com/aurora-is-near/relayer2-base/types/event"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+)
+
+// sweepInterval limits how often the expired transactions and filters are looked for
+const sweepInterval = time.Minute
+
+// Transaction is a transaction submitted by the relayer which is not seen in a block yet
+type Transaction struct {
+	Hash primitives.Data32
+	// Tx is only set for the transactions added with AddTransaction
+	Tx      *response.Transaction
+	AddedAt time.Time
+	seq     uint64
+	// elem is the element of the transaction in the order of its chain
+	elem *list.Element
+}
+
+type filter struct {
+	// cursor is the sequence number of the last transaction returned by the filter
+	cursor       uint64
+	lastPolledAt time.Time
+}
+
+// Pool keeps the hashes of the transactions submitted by the relayer until InsertBlock sees them (see
+// db.TxPoolBlockHandler), so that they can be served by eth_pendingTransactions, parity_pendingTransactions and
+// the pending transaction filters. Everything is kept in memory and per chain.
+//
+// If Broker is set, the added transactions are published to the newPendingTransactions subscribers.
+type Pool struct {
+	Config *Config
+	Broker broker.Broker
+
+	mtx     sync.Mutex
+	txs     map[uint64]map[string]*Transaction
+	filters map[uint64]map[string]*filter
+	// order keeps the pending transactions of each chain in the order they were added, the oldest first, so that
+	// the oldest ones are evicted without scanning the pool
+	order map[uint64]*list.List
+	// added keeps the transactions of each chain in the order they were added until every filter of the chain
+	// returned them, so that the filters do not miss the ones included in a block between two polls. It is capped
+	// at Config.MaxSize transactions.
+	added     map[uint64][]*Transaction
+	seq       uint64
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func New() *Pool {
+	return NewWithConfig(GetConfig())
+}
+
+func NewWithConfig(config *Config) *Pool {
+	return &Pool{
+		Config:  config,
+		txs:     map[uint64]map[string]*Transaction{},
+		order:   map[uint64]*list.List{},
+		filters: map[uint64]map[string]*filter{},
+		added:   map[uint64][]*Transaction{},
+		now:     time.Now,
+	}
+}
+
+// Add records a submitted transaction, returns false if it is already pending
+func (p *Pool) Add(chainId uint64, hash primitives.Data32) bool {
+	return p.add(chainId, hash, nil)
+}
+
+// AddTransaction records a submitted transaction together with its details, which are served to the subscribers asking
+// for full transaction objects, returns false if it is already pending
+func (p *Pool) AddTransaction(chainId uint64, tx *response.Transaction) bool {
+	return p.add(chainId, tx.Hash, tx)
+}
+
+func (p *Pool) add(chainId uint64, hash primitives.Data32, tx *response.Transaction) bool {
+	if !p.insert(chainId, hash, tx) {
+		return false
+	}
+	if p.Broker != nil {
+		p.Broker.PublishPendingTransaction(event.Transaction{ChainId: chainId, Hash: hash, Tx: tx})
+	}
+	return true
+}
+
+func (p *Pool) insert(chainId uint64, hash primitives.Data32, tx *response.Transaction) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	now := p.now()
+	p.sweep(now)
+	txs := p.txs[chainId]
+	if txs == nil {
+		txs = map[string]*Transaction{}
+		p.txs[chainId] = txs
+		p.order[chainId] = list.New()
+	}
+	key := string(hash.Bytes())
+	if _, ok := txs[key]; ok {
+		return false
+	}
+	order := p.order[chainId]
+	if p.Config.MaxSize > 0 && len(txs) >= p.Config.MaxSize {
+		p.delete(chainId, order.Front().Value.(*Transaction))
+	}
+	p.seq++
+	txs[key] = &Transaction{Hash: hash, Tx: tx, AddedAt: now, seq: p.seq}
+	txs[key].elem = order.PushBack(txs[key])
+	if len(p.filters[chainId]) > 0 {
+		added := append(p.added[chainId], txs[key])
+		if p.Config.MaxSize > 0 && len(added) > p.Config.MaxSize {
+			added = added[len(added)-p.Config.MaxSize:]
+		}
+		p.added[chainId] = added
+	}
+	return true
+}
+
+// Remove evicts the given transactions, returns the number of evicted ones
+func (p *Pool) Remove(chainId uint64, hashes ...primitives.Data32) int {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	txs := p.txs[chainId]
+	n := 0
+	for _, hash := range hashes {
+		if tx, ok := txs[string(hash.Bytes())]; ok {
+			p.delete(chainId, tx)
+			n++
+		}
+	}
+	return n
+}
+
+// delete removes the pending transaction of the chain, it is still returned by the filters which did not return it yet
+func (p *Pool) delete(chainId uint64, tx *Transaction) {
+	delete(p.txs[chainId], string(tx.Hash.Bytes()))
+	p.order[chainId].Remove(tx.elem)
+}
+
+// Pending returns up to limit pending transactions of the chain in the order they were added, all of them if limit is
+// not positive
+func (p *Pool) Pending(chainId uint64, limit int) []*Transaction {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	now := p.now()
+	p.sweep(now)
+	return p.since(chainId, 0, limit, now)
+}
+
+// NewFilter installs a pending transaction filter, which returns the transactions added after its creation
+func (p *Pool) NewFilter(chainId uint64, filterId primitives.Data32) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	filters := p.filters[chainId]
+	if filters == nil {
+		filters = map[string]*filter{}
+		p.filters[chainId] = filters
+	}
+	filters[string(filterId.Bytes())] = &filter{cursor: p.seq, lastPolledAt: p.now()}
+}
+
+// FilterChanges returns the transactions which are added since the previous poll of the filter, including the ones
+// which are not pending anymore, the second return value is false if the filter is not installed or expired
+func (p *Pool) FilterChanges(chainId uint64, filterId primitives.Data32) ([]*Transaction, bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	now := p.now()
+	p.sweep(now)
+	f, ok := p.filters[chainId][string(filterId.Bytes())]
+	if !ok || p.filterExpired(f, now) {
+		return nil, false
+	}
+	added := p.added[chainId]
+	i := sort.Search(len(added), func(i int) bool {
+		return added[i].seq > f.cursor
+	})
+	txs := append(make([]*Transaction, 0, len(added)-i), added[i:]...)
+	f.cursor = p.seq
+	f.lastPolledAt = now
+	p.trim(chainId)
+	return txs, true
+}
+
+// UninstallFilter removes the filter, returns false if it is not installed
+func (p *Pool) UninstallFilter(chainId uint64, filterId primitives.Data32) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	key := string(filterId.Bytes())
+	f, ok := p.filters[chainId][key]
+	if !ok {
+		return false
+	}
+	delete(p.filters[chainId], key)
+	p.trim(chainId)
+	return !p.filterExpired(f, p.now())
+}
+
+// trim drops the added transactions of the chain which are returned by all of its filters
+func (p *Pool) trim(chainId uint64) {
+	added := p.added[chainId]
+	if len(added) == 0 {
+		return
+	}
+	cursor := p.seq
+	for _, f := range p.filters[chainId] {
+		if f.cursor < cursor {
+			cursor = f.cursor
+		}
+	}
+	i := sort.Search(len(added), func(i int) bool {
+		return added[i].seq > cursor
+	})
+	if i == len(added) {
+		delete(p.added, chainId)
+		return
+	}
+	p.added[chainId] = added[i:]
+}
+
+// since returns the transactions of the chain with a sequence number greater than seq, ordered by sequence number
+func (p *Pool) since(chainId uint64, seq uint64, limit int, now time.Time) []*Transaction {
+	res := make([]*Transaction, 0)
+	order := p.order[chainId]
+	if order == nil {
+		return res
+	}
+	for e := order.Front(); e != nil && (limit <= 0 || len(res) < limit); e = e.Next() {
+		tx := e.Value.(*Transaction)
+		if tx.seq > seq && !p.txExpired(tx, now) {
+			res = append(res, tx)
+		}
+	}
+	return res
+}
+
+// sweep deletes the expired transactions and filters, at most once per sweepInterval
+func (p *Pool) sweep(now time.Time) {
+	if now.Sub(p.lastSweep) < sweepInterval {
+		return
+	}
+	p.lastSweep = now
+	for chainId, order := range p.order {
+		// the transactions are ordered by age, so the expired ones are at the front
+		for e := order.Front(); e != nil && p.txExpired(e.Value.(*Transaction), now); e = order.Front() {
+			p.delete(chainId, e.Value.(*Transaction))
+		}
+	}
+	for chainId, filters := range p.filters {
+		for key, f := range filters {
+			if p.filterExpired(f, now) {
+				delete(filters, key)
+			}
+		}
+		p.trim(chainId)
+	}
+}
+
+func (p *Pool) txExpired(tx *Transaction, now time.Time) bool {
+	return p.Config.MaxAgeMinutes > 0 && now.Sub(tx.AddedAt) > time.Duration(p.Config.MaxAgeMinutes)*time.Minute
+}
+
+func (p *Pool) filterExpired(f *filter, now time.Time) bool {
+	return p.Config.FilterTtlMinutes > 0 && now.Sub(f.lastPolledAt) > time.Duration(p.Config.FilterTtlMinutes)*time.Minute
+}
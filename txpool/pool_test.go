@@ -0,0 +1,133 @@
+package txpool
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aurora-is-near/relayer2-base/broker"
+	"github.com/aurora-is-near/relayer2-base/types/event"
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func hash(i int) primitives.Data32 {
+	return primitives.MustData32FromHex(fmt.Sprintf("0x%064x", i))
+}
+
+func hashes(txs []*Transaction) []primitives.Data32 {
+	res := make([]primitives.Data32, 0, len(txs))
+	for _, tx := range txs {
+		res = append(res, tx.Hash)
+	}
+	return res
+}
+
+func TestPool(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	p := NewWithConfig(&Config{MaxSize: 3, MaxAgeMinutes: 30, FilterTtlMinutes: 15})
+	p.now = func() time.Time { return now }
+
+	assert.True(t, p.Add(1, hash(1)))
+	assert.False(t, p.Add(1, hash(1)), "already pending")
+	assert.True(t, p.Add(2, hash(1)), "another chain")
+	p.NewFilter(1, hash(100))
+	assert.True(t, p.Add(1, hash(2)))
+	assert.True(t, p.Add(1, hash(3)))
+	assert.Equal(t, []primitives.Data32{hash(1), hash(2), hash(3)}, hashes(p.Pending(1, 0)))
+	assert.Equal(t, []primitives.Data32{hash(1), hash(2)}, hashes(p.Pending(1, 2)))
+
+	assert.True(t, p.Add(1, hash(4)))
+	assert.Equal(t, []primitives.Data32{hash(2), hash(3), hash(4)}, hashes(p.Pending(1, 0)), "oldest evicted")
+
+	assert.Equal(t, 1, p.Remove(1, hash(3), hash(5)))
+	assert.Equal(t, len(p.txs[1]), p.order[1].Len(), "removed transactions dropped from the order")
+	txs, ok := p.FilterChanges(1, hash(100))
+	assert.True(t, ok)
+	assert.Equal(t, []primitives.Data32{hash(2), hash(3), hash(4)}, hashes(txs), "included transactions not missed")
+	txs, ok = p.FilterChanges(1, hash(100))
+	assert.True(t, ok)
+	assert.Empty(t, txs)
+	_, ok = p.FilterChanges(2, hash(100))
+	assert.False(t, ok, "filter of another chain")
+
+	now = now.Add(20 * time.Minute)
+	assert.True(t, p.Add(1, hash(5)))
+	_, ok = p.FilterChanges(1, hash(100))
+	assert.False(t, ok, "expired filter")
+	assert.False(t, p.UninstallFilter(1, hash(100)))
+
+	now = now.Add(20 * time.Minute)
+	assert.Equal(t, []primitives.Data32{hash(5)}, hashes(p.Pending(1, 0)), "stale transactions evicted")
+	assert.Equal(t, 1, p.order[1].Len())
+	assert.Empty(t, p.Pending(2, 0))
+
+	p.NewFilter(1, hash(101))
+	assert.True(t, p.UninstallFilter(1, hash(101)))
+	_, ok = p.FilterChanges(1, hash(101))
+	assert.False(t, ok)
+}
+
+func TestPoolFilterLog(t *testing.T) {
+	p := NewWithConfig(&Config{MaxSize: 3, FilterTtlMinutes: 15})
+
+	assert.True(t, p.Add(1, hash(1)))
+	p.NewFilter(1, hash(100))
+	p.NewFilter(1, hash(101))
+	assert.True(t, p.Add(1, hash(2)))
+	assert.Equal(t, 1, p.Remove(1, hash(2)))
+	assert.True(t, p.Add(1, hash(3)))
+
+	txs, ok := p.FilterChanges(1, hash(100))
+	assert.True(t, ok)
+	assert.Equal(t, []primitives.Data32{hash(2), hash(3)}, hashes(txs))
+	assert.Len(t, p.added[1], 2, "transactions kept until every filter returned them")
+
+	assert.True(t, p.Add(1, hash(4)))
+	txs, ok = p.FilterChanges(1, hash(101))
+	assert.True(t, ok)
+	assert.Equal(t, []primitives.Data32{hash(2), hash(3), hash(4)}, hashes(txs))
+	assert.Len(t, p.added[1], 1)
+
+	assert.True(t, p.UninstallFilter(1, hash(100)))
+	assert.Empty(t, p.added[1], "transactions dropped once returned by the remaining filters")
+
+	for i := 5; i < 10; i++ {
+		assert.True(t, p.Add(1, hash(i)))
+	}
+	txs, ok = p.FilterChanges(1, hash(101))
+	assert.True(t, ok)
+	assert.Equal(t, []primitives.Data32{hash(7), hash(8), hash(9)}, hashes(txs), "log capped at MaxSize")
+
+	assert.True(t, p.UninstallFilter(1, hash(101)))
+	assert.True(t, p.Add(1, hash(10)))
+	assert.Empty(t, p.added[1], "transactions not logged without filters")
+}
+
+type recordingBroker struct {
+	broker.Broker
+	txs []event.Transaction
+}
+
+func (b *recordingBroker) PublishPendingTransaction(tx event.Transaction) { b.txs = append(b.txs, tx) }
+
+func TestPoolPublish(t *testing.T) {
+	b := &recordingBroker{}
+	p := NewWithConfig(&Config{})
+	p.Broker = b
+
+	assert.True(t, p.Add(1, hash(1)))
+	assert.False(t, p.Add(1, hash(1)), "already pending transactions must not be published again")
+	nonce := primitives.QuantityFromHex("0x7")
+	assert.True(t, p.AddTransaction(2, &response.Transaction{Hash: hash(2), Nonce: nonce}))
+
+	assert.Len(t, b.txs, 2)
+	assert.Equal(t, uint64(1), b.txs[0].ChainId)
+	assert.Equal(t, hash(1), b.txs[0].Hash)
+	assert.Nil(t, b.txs[0].Tx, "only the hash is published if the transaction is not known")
+	assert.Equal(t, uint64(2), b.txs[1].ChainId, "transactions published with their chain")
+	assert.Equal(t, hash(2), b.txs[1].Hash)
+	assert.Equal(t, nonce, b.txs[1].Tx.Nonce)
+	assert.Equal(t, nonce, p.Pending(2, 0)[0].Tx.Nonce)
+}
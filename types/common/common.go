@@ -103,6 +103,15 @@ func (bnh *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
 		bnh.RequireCanonical = e.RequireCanonical
 		return nil
 	}
+	// a plain block hash is accepted as well, only a full length hash is considered so that it is not confused with
+	// a zero padded block number
+	if len(data) == len(`"0x"`)+2*32 {
+		var hash H256
+		if err := jsoniter.Unmarshal(data, &hash); err == nil {
+			bnh.BlockHash = &hash
+			return nil
+		}
+	}
 	var input BN64
 	err = jsoniter.Unmarshal(data, &input)
 	if err != nil {
@@ -39,6 +39,8 @@ func TestBlockNumberOrHash_UnmarshalJSON(t *testing.T) {
 		22: {`{"blockNumber":"latest"}`, false, BlockNumberOrHashWithBN64(LatestBlockNumber)},
 		23: {`{"blockNumber":"earliest"}`, false, BlockNumberOrHashWithBN64(EarliestBlockNumber)},
 		24: {`{"blockNumber":"0x1", "blockHash":"0x0000000000000000000000000000000000000000000000000000000000000000"}`, true, BlockNumberOrHash{}},
+		25: {`"0x0000000000000000000000000000000000000000000000000000000000000001"`, false, BlockNumberOrHashWithHash(MustHexStringToHash("0x0000000000000000000000000000000000000000000000000000000000000001"), false)},
+		26: {`"0x000000000000000000000000000000000000000000000000000000000000000g"`, true, BlockNumberOrHash{}},
 	}
 
 	for i, test := range tests {
@@ -1,16 +1,19 @@
 package db
 
 import (
+	"time"
+
 	tp "github.com/aurora-is-near/relayer2-base/tinypack"
 	"github.com/aurora-is-near/relayer2-base/types/primitives"
 )
 
 type BlockFilter struct {
-	CreatedAt uint64
-	Metadata  primitives.VarData
-	From      BlockKey
-	Next      BlockKey
-	To        BlockKey
+	CreatedAt    uint64
+	Metadata     primitives.VarData
+	From         BlockKey
+	Next         BlockKey
+	To           BlockKey
+	LastPolledAt uint64
 }
 
 func (f *BlockFilter) GetTinyPackChildrenPointers() ([]any, error) {
@@ -20,15 +23,17 @@ func (f *BlockFilter) GetTinyPackChildrenPointers() ([]any, error) {
 		&f.From,
 		&f.Next,
 		&f.To,
+		&f.LastPolledAt,
 	}, nil
 }
 
 type TransactionFilter struct {
-	CreatedAt uint64
-	Metadata  primitives.VarData
-	From      TransactionKey
-	Next      TransactionKey
-	To        TransactionKey
+	CreatedAt    uint64
+	Metadata     primitives.VarData
+	From         TransactionKey
+	Next         TransactionKey
+	To           TransactionKey
+	LastPolledAt uint64
 }
 
 func (f *TransactionFilter) GetTinyPackChildrenPointers() ([]any, error) {
@@ -38,17 +43,19 @@ func (f *TransactionFilter) GetTinyPackChildrenPointers() ([]any, error) {
 		&f.From,
 		&f.Next,
 		&f.To,
+		&f.LastPolledAt,
 	}, nil
 }
 
 type LogFilter struct {
-	CreatedAt uint64
-	Metadata  primitives.VarData
-	From      LogKey
-	Next      LogKey
-	To        LogKey
-	Addresses tp.VarList[primitives.Data20]
-	Topics    tp.VarList[tp.VarList[primitives.Data32]]
+	CreatedAt    uint64
+	Metadata     primitives.VarData
+	From         LogKey
+	Next         LogKey
+	To           LogKey
+	Addresses    tp.VarList[primitives.Data20]
+	Topics       tp.VarList[tp.VarList[primitives.Data32]]
+	LastPolledAt uint64
 }
 
 func (f *LogFilter) GetTinyPackChildrenPointers() ([]any, error) {
@@ -60,5 +67,26 @@ func (f *LogFilter) GetTinyPackChildrenPointers() ([]any, error) {
 		&f.To,
 		&f.Addresses,
 		&f.Topics,
+		&f.LastPolledAt,
 	}, nil
 }
+
+// Expired returns true if the filter has not been polled for longer than ttl, filters never expire if ttl is not positive
+func (f *BlockFilter) Expired(now time.Time, ttl time.Duration) bool {
+	return expired(f.LastPolledAt, now, ttl)
+}
+
+// Expired returns true if the filter has not been polled for longer than ttl, filters never expire if ttl is not positive
+func (f *TransactionFilter) Expired(now time.Time, ttl time.Duration) bool {
+	return expired(f.LastPolledAt, now, ttl)
+}
+
+// Expired returns true if the filter has not been polled for longer than ttl, filters never expire if ttl is not positive
+func (f *LogFilter) Expired(now time.Time, ttl time.Duration) bool {
+	return expired(f.LastPolledAt, now, ttl)
+}
+
+// expired treats the filters stored before LastPolledAt was introduced (i.e. having it unset) as polled just now
+func expired(lastPolledAt uint64, now time.Time, ttl time.Duration) bool {
+	return ttl > 0 && lastPolledAt > 0 && now.Sub(time.Unix(0, int64(lastPolledAt))) > ttl
+}
@@ -34,6 +34,12 @@ func (lk LogKey) Prev() *LogKey {
 	return &lk
 }
 
+// BlockLogKeys returns the first and the last possible keys of the logs of the block at the given height
+func BlockLogKeys(height uint64) (LogKey, LogKey) {
+	return LogKey{BlockHeight: height},
+		LogKey{BlockHeight: height, TransactionIndex: dbkey.MaxTxIndex, LogIndex: dbkey.MaxLogIndex}
+}
+
 func (lk LogKey) Next() *LogKey {
 	if lk.LogIndex == dbkey.MaxLogIndex {
 		if lk.TransactionIndex == dbkey.MaxTxIndex {
@@ -2,6 +2,9 @@ package errors
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"time"
 )
 
 const (
@@ -18,7 +21,10 @@ const (
 	ParseError            = -32700
 
 	// -32900 to -32999 space is reserved for Aurora Relayer application specific errors.
-	KeyNotFound = -32900
+	KeyNotFound       = -32900
+	RateLimitExceeded = -32901
+	Pruned            = -32902
+	SlowConsumer      = -32903
 )
 
 type Error interface {
@@ -82,6 +88,38 @@ func (e *KeyNotFoundError) Error() string {
 	return "record not found in DB"
 }
 
+// client exceeded its request budget, RetryAfter is the time until the budget allows the call again
+type RateLimitError struct{ RetryAfter time.Duration }
+
+func (e *RateLimitError) ErrorCode() int { return RateLimitExceeded }
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter.Round(time.Millisecond))
+}
+
+// ErrorData returns the retry-after hint in whole seconds, rounded up as in the HTTP Retry-After header
+func (e *RateLimitError) ErrorData() string {
+	return strconv.FormatInt(int64(math.Max(1, math.Ceil(e.RetryAfter.Seconds()))), 10)
+}
+
+// requested block is below the retention window of the node and has been pruned
+type PrunedError struct{ EarliestHeight uint64 }
+
+func (e *PrunedError) ErrorCode() int { return Pruned }
+
+func (e *PrunedError) Error() string {
+	return fmt.Sprintf("requested data has been pruned, earliest available block is %d", e.EarliestHeight)
+}
+
+// subscription is closed since the client does not read its notifications fast enough
+type SlowConsumerError struct{}
+
+func (e *SlowConsumerError) ErrorCode() int { return SlowConsumer }
+
+func (e *SlowConsumerError) Error() string {
+	return "subscription closed, notifications are not consumed fast enough"
+}
+
 type LogResponseRangeLimitError struct{ Err error }
 
 func (e *LogResponseRangeLimitError) ErrorCode() int { return LogRangeLimitExceeded }
@@ -106,6 +144,16 @@ func (e *TxsRevertError) Error() string { return e.Message }
 
 func (e *TxsRevertError) ErrorData() string { return e.Data }
 
+// error object returned by the upstream of a proxied endpoint, its code is passed through as is
+type UpstreamError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *UpstreamError) ErrorCode() int { return e.Code }
+
+func (e *UpstreamError) Error() string { return e.Message }
+
 type InternalError struct{ Message string }
 
 func (e *InternalError) ErrorCode() int { return Internal }
@@ -1,7 +1,18 @@
 package event
 
-import "github.com/aurora-is-near/relayer2-base/types/response"
+import (
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	"github.com/aurora-is-near/relayer2-base/types/response"
+)
 
 type Block *response.Block
 
 type Logs []*response.Log
+
+// Transaction is a pending transaction of the given chain
+type Transaction struct {
+	ChainId uint64            `json:"chainId"`
+	Hash    primitives.Data32 `json:"hash"`
+	// Tx is nil if only the hash of the transaction is known
+	Tx *response.Transaction `json:"tx,omitempty"`
+}
@@ -50,13 +50,15 @@ func (f *Filter) ToLogFilter() *db.LogFilter {
 		topic := tinypack.VarList[primitives.Data32]{tinypack.CreateList[primitives.VarLen, primitives.Data32](t...)}
 		topics = append(topics, topic)
 	}
+	now := uint64(time.Now().UnixNano())
 	return &db.LogFilter{
-		CreatedAt: uint64(time.Now().UnixNano()),
-		Metadata:  primitives.VarDataFromBytes(nil),
-		From:      db.LogKey{BlockHeight: fb, TransactionIndex: ft, LogIndex: fl},
-		To:        db.LogKey{BlockHeight: tb, TransactionIndex: tt, LogIndex: tl},
-		Addresses: tinypack.VarList[primitives.Data20]{tinypack.CreateList[primitives.VarLen, primitives.Data20](f.Addresses...)},
-		Topics:    tinypack.VarList[tinypack.VarList[primitives.Data32]]{tinypack.CreateList[primitives.VarLen, tinypack.VarList[primitives.Data32]](topics...)},
+		CreatedAt:    now,
+		LastPolledAt: now,
+		Metadata:     primitives.VarDataFromBytes(nil),
+		From:         db.LogKey{BlockHeight: fb, TransactionIndex: ft, LogIndex: fl},
+		To:           db.LogKey{BlockHeight: tb, TransactionIndex: tt, LogIndex: tl},
+		Addresses:    tinypack.VarList[primitives.Data20]{tinypack.CreateList[primitives.VarLen, primitives.Data20](f.Addresses...)},
+		Topics:       tinypack.VarList[tinypack.VarList[primitives.Data32]]{tinypack.CreateList[primitives.VarLen, tinypack.VarList[primitives.Data32]](topics...)},
 	}
 }
 
@@ -68,11 +70,13 @@ func (f *Filter) ToBlockFilter() *db.BlockFilter {
 	if f.ToBlock != nil {
 		tb = *f.ToBlock
 	}
+	now := uint64(time.Now().UnixNano())
 	return &db.BlockFilter{
-		CreatedAt: uint64(time.Now().UnixNano()),
-		Metadata:  primitives.VarDataFromBytes(nil),
-		From:      db.BlockKey{Height: fb},
-		To:        db.BlockKey{Height: tb},
+		CreatedAt:    now,
+		LastPolledAt: now,
+		Metadata:     primitives.VarDataFromBytes(nil),
+		From:         db.BlockKey{Height: fb},
+		To:           db.BlockKey{Height: tb},
 	}
 }
 
@@ -91,10 +95,12 @@ func (f *Filter) ToTxnFilter() *db.TransactionFilter {
 		tt = *f.ToTxn
 	}
 
+	now := uint64(time.Now().UnixNano())
 	return &db.TransactionFilter{
-		CreatedAt: uint64(time.Now().UnixNano()),
-		Metadata:  primitives.VarDataFromBytes(nil),
-		From:      db.TransactionKey{BlockHeight: fb, TransactionIndex: ft},
-		To:        db.TransactionKey{BlockHeight: tb, TransactionIndex: tt},
+		CreatedAt:    now,
+		LastPolledAt: now,
+		Metadata:     primitives.VarDataFromBytes(nil),
+		From:         db.TransactionKey{BlockHeight: fb, TransactionIndex: ft},
+		To:           db.TransactionKey{BlockHeight: tb, TransactionIndex: tt},
 	}
 }
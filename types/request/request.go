@@ -15,6 +15,13 @@ type SingleOrSliceOfAddress []common.Address
 type LogSubscriptionOptions struct {
 	Address []common.Address `json:"address"`
 	Topics  Topics           `json:"topics"`
+	// FromBlock makes the subscription replay the logs from the given block before the live ones
+	FromBlock *common.BN64 `json:"fromBlock"`
+}
+
+type NewHeadsSubscriptionOptions struct {
+	// FromBlock makes the subscription replay the heads from the given block before the live ones
+	FromBlock *common.BN64 `json:"fromBlock"`
 }
 
 type Filter struct {
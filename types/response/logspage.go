@@ -0,0 +1,8 @@
+package response
+
+// LogsPage holds a page of the logs matching a filter. Cursor is only set if there are more logs to read, it should
+// be sent back along with the same filter to get the next page.
+type LogsPage struct {
+	Logs   []*Log  `json:"logs"`
+	Cursor *string `json:"cursor"`
+}
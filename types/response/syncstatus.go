@@ -0,0 +1,52 @@
+package response
+
+import (
+	"bytes"
+
+	"github.com/aurora-is-near/relayer2-base/types/primitives"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// https://ethereum.org/en/developers/docs/apis/json-rpc/#eth_syncing
+// SyncStatus is encoded as `false` if Syncing is false, as the sync progress object otherwise
+type SyncStatus struct {
+	Syncing       bool               `json:"-"`
+	StartingBlock primitives.HexUint `json:"startingBlock"`
+	CurrentBlock  primitives.HexUint `json:"currentBlock"`
+	HighestBlock  primitives.HexUint `json:"highestBlock"`
+}
+
+type syncProgress struct {
+	StartingBlock primitives.HexUint `json:"startingBlock"`
+	CurrentBlock  primitives.HexUint `json:"currentBlock"`
+	HighestBlock  primitives.HexUint `json:"highestBlock"`
+}
+
+func (s SyncStatus) MarshalJSON() ([]byte, error) {
+	if !s.Syncing {
+		return []byte("false"), nil
+	}
+	return jsoniter.Marshal(syncProgress{
+		StartingBlock: s.StartingBlock,
+		CurrentBlock:  s.CurrentBlock,
+		HighestBlock:  s.HighestBlock,
+	})
+}
+
+func (s *SyncStatus) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("false")) {
+		*s = SyncStatus{}
+		return nil
+	}
+	var p syncProgress
+	if err := jsoniter.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*s = SyncStatus{
+		Syncing:       true,
+		StartingBlock: p.StartingBlock,
+		CurrentBlock:  p.CurrentBlock,
+		HighestBlock:  p.HighestBlock,
+	}
+	return nil
+}
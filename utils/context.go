@@ -2,6 +2,7 @@ package utils
 
 import (
 	"net"
+	"time"
 
 	"github.com/aurora-is-near/relayer2-base/log"
 
@@ -113,6 +114,22 @@ func PutClientIpKey(ctx context.Context, ip net.IP) context.Context {
 	return context.WithValue(ctx, clientIpKey{}, ip)
 }
 
+// WithoutCancel returns a context which keeps the values of ctx but is never canceled, e.g. for the work outliving the
+// request which started it
+func WithoutCancel(ctx context.Context) context.Context {
+	return withoutCancel{ctx}
+}
+
+type withoutCancel struct {
+	context.Context
+}
+
+func (withoutCancel) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (withoutCancel) Done() <-chan struct{} { return nil }
+
+func (withoutCancel) Err() error { return nil }
+
 // ClientIpFromContext returns the clientIp value stored in ctx, if any.
 func ClientIpFromContext(ctx context.Context) (*net.IP, bool) {
 	ip, ok := ctx.Value(clientIpKey{}).(net.IP)
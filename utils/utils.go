@@ -114,6 +114,91 @@ func IndexerLogToDbLog(log *indexer.Log) *dbt.Log {
 	return &l
 }
 
+// DbBlockToIndexerBlock is the inverse of IndexerBlockToDbBlock, the transactions of the returned block are left empty
+func DbBlockToIndexerBlock(chainId, height uint64, hash primitives.Data32, block *dbt.Block) *indexer.Block {
+	b := indexer.Block{
+		ChainId:          chainId,
+		Height:           height,
+		Hash:             hash,
+		ParentHash:       block.ParentHash,
+		Miner:            block.Miner,
+		Timestamp:        indexer.Timestamp(block.Timestamp),
+		GasLimit:         block.GasLimit,
+		GasUsed:          block.GasUsed,
+		LogsBloom:        block.LogsBloom,
+		TransactionsRoot: block.TransactionsRoot,
+		StateRoot:        block.StateRoot,
+		ReceiptsRoot:     block.ReceiptsRoot,
+		Size:             indexer.Size(block.Size),
+		Transactions:     []*indexer.Transaction{},
+	}
+	return &b
+}
+
+// DbTxnToIndexerTxn is the inverse of IndexerTxnToDbTxn, the logs of the returned transaction are left empty
+func DbTxnToIndexerTxn(chainId, height, index uint64, blockHash, hash primitives.Data32, txn *dbt.Transaction) *indexer.Transaction {
+	var accessList []indexer.AccessList
+	for _, ale := range txn.AccessList.Content {
+		accessList = append(accessList, indexer.AccessList{
+			Address:     ale.Address,
+			StorageKeys: ale.StorageKeys.Content,
+		})
+	}
+
+	t := indexer.Transaction{
+		Hash:                 hash,
+		BlockHash:            blockHash,
+		BlockHeight:          height,
+		ChainId:              chainId,
+		TransactionIndex:     index,
+		From:                 txn.From,
+		Nonce:                txn.Nonce,
+		GasPrice:             txn.GasPrice,
+		GasLimit:             txn.GasLimit,
+		GasUsed:              txn.GasUsed,
+		MaxPriorityFeePerGas: txn.MaxPriorityFeePerGas,
+		MaxFeePerGas:         txn.MaxFeePerGas,
+		Value:                txn.Value,
+		Input:                indexer.InputOutputData(txn.Input),
+		AccessList:           accessList,
+		TxType:               txn.Type,
+		Status:               txn.Status,
+		Logs:                 []*indexer.Log{},
+		LogsBloom:            txn.LogsBloom,
+		V:                    txn.V,
+		R:                    txn.R,
+		S:                    txn.S,
+		NearTransaction: indexer.NearTransaction{
+			ReceiptHash: indexer.NearHash(txn.NearReceiptHash),
+		},
+	}
+	if txn.IsContractDeployment {
+		t.ContractAddress = txn.ToOrContract.Ptr
+	} else {
+		t.To = txn.ToOrContract.Ptr
+	}
+	if txn.NearHash.Ptr != nil {
+		nh := indexer.NearHash(*txn.NearHash.Ptr)
+		t.NearTransaction.Hash = &nh
+	}
+	return &t
+}
+
+// DbLogToIndexerLog is the inverse of IndexerLogToDbLog
+func DbLogToIndexerLog(log *dbt.Log) *indexer.Log {
+	topics := make([]indexer.Topic, 0, len(log.Topics.Content))
+	for _, t := range log.Topics.Content {
+		topics = append(topics, indexer.Topic(t))
+	}
+
+	l := indexer.Log{
+		Address: log.Address,
+		Data:    indexer.InputOutputData(log.Data),
+		Topics:  topics,
+	}
+	return &l
+}
+
 func ComputeBlockHash(bHeight, chainId uint64) []byte {
 	bufEmpty25 := make([]byte, 25)
 